@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// addressLayouts are the postal address layouts by ISO 3166 country code.
+// The {field} placeholders are replaced by the address fields.
+var addressLayouts = map[string][]string{
+	"US": {"{name}", "{street}", "{city}, {state} {postcode}"},
+	"CA": {"{name}", "{street}", "{city} {state}  {postcode}"},
+	"AU": {"{name}", "{street}", "{city} {state} {postcode}"},
+	"GB": {"{name}", "{street}", "{city}", "{postcode}"},
+	"IE": {"{name}", "{street}", "{city}", "{state}", "{postcode}"},
+	"BR": {"{name}", "{street}", "{city} - {state}", "{postcode}"},
+	"JP": {"〒{postcode}", "{state}{city}{street}", "{name}"},
+	"CN": {"{postcode}", "{state}{city}{street}", "{name}"},
+	"RU": {"{name}", "{street}", "{city}", "{state}", "{postcode}"},
+	"IT": {"{name}", "{street}", "{postcode} {city} {state}"},
+	"ES": {"{name}", "{street}", "{postcode} {city}", "{state}"},
+}
+
+// defaultAddressLayout is the layout of the countries not in addressLayouts,
+// used in most of Europe (street, then postcode and city).
+var defaultAddressLayout = []string{"{name}", "{street}", "{postcode} {city}", "{state}"}
+
+// addressFieldRe matches the {field} placeholders of a layout.
+var addressFieldRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// formatAddress formats the address fields (name, street, postcode, city, state,
+// with case-insensitive keys, e.g. a row or a dict) with the layout of the country,
+// one line per address line; empty lines are dropped.
+// The fields can also be given under the aliases zip, address and region.
+func formatAddress(country string, fields any) (string, error) {
+	values, ok := fields.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("formatAddress: the fields must be a row or a dict, not %T", fields)
+	}
+	lookup := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != nil {
+			lookup[strings.ToLower(k)] = strings.TrimSpace(fmt.Sprint(v))
+		}
+	}
+	for alias, field := range map[string]string{"zip": "postcode", "address": "street", "region": "state"} {
+		if lookup[field] == "" {
+			lookup[field] = lookup[alias]
+		}
+	}
+	layout, ok := addressLayouts[strings.ToUpper(strings.TrimSpace(country))]
+	if !ok {
+		layout = defaultAddressLayout
+	}
+	var lines []string
+	for _, l := range layout {
+		line := addressFieldRe.ReplaceAllStringFunc(l, func(p string) string {
+			return lookup[p[1:len(p)-1]]
+		})
+		// drop the lines of empty fields and the separators left by them
+		if strings.Trim(line, " ,-〒") == "" {
+			continue
+		}
+		line = strings.ReplaceAll(strings.Trim(line, " ,-"), " ,", ",")
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_formatAddress() {
+	row := map[string]any{"Name": "Ann Lee", "Street": "1 Main St", "City": "Springfield", "State": "IL", "Zip": "62701"}
+	us, _ := formatAddress("us", row)
+	fmt.Println(us)
+	fr, _ := formatAddress("FR", row)
+	fmt.Println(fr)
+	// Output:
+	// Ann Lee
+	// 1 Main St
+	// Springfield, IL 62701
+	// Ann Lee
+	// 1 Main St
+	// 62701 Springfield
+	// IL
+}
+
+func TestFormatAddressEmptyFields(t *testing.T) {
+	got, err := formatAddress("US", map[string]any{"name": "Ann", "city": "Springfield"})
+	if err != nil || got != "Ann\nSpringfield" {
+		t.Errorf("formatAddress = %q, %v", got, err)
+	}
+	if _, err := formatAddress("US", "Ann"); err == nil {
+		t.Error("string fields: no error")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+)
+
+// dataset is the dot of the template in single file mode: the slice of all rows,
+// with some aggregated information (.Count, .Rows) available as methods.
+type dataset []map[string]any
+
+// Rows returns the rows of the dataset.
+func (d dataset) Rows() []map[string]any {
+	return d
+}
+
+// Count returns the number of data rows (the totals row is not counted).
+func (d dataset) Count() int {
+	var n int
+	for _, row := range d {
+		if row[totalField] != true {
+			n++
+		}
+	}
+	return n
+}
+
+// dataRows returns the rows of list (a dataset, the .Rows of a group or any slice of rows),
+// without the totals rows.
+func dataRows(function string, list any) ([]map[string]any, error) {
+	v, err := sliceValue(function, list)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]any, 0, v.Len())
+	for i := range v.Len() {
+		row, ok := v.Index(i).Interface().(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %T is not a row", function, v.Index(i).Interface())
+		}
+		if row[totalField] != true {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// aggregateBy returns the template function computing the op (see total) of a column of rows.
+func aggregateBy(function, op string) func(column string, list any) (any, error) {
+	return func(column string, list any) (any, error) {
+		rows, err := dataRows(function, list)
+		if err != nil {
+			return nil, err
+		}
+		return total{column: column, op: op}.compute(rows), nil
+	}
+}
+
+// countBy returns the number of rows for every value of the column:
+// {{range $city, $n := countBy "City" .}}.
+func countBy(column string, list any) (map[string]int, error) {
+	rows, err := dataRows("countBy", list)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, row := range rows {
+		v, _ := getField(row, column)
+		counts[fmt.Sprint(v)]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	csv := "City,Amount\nParis,10\nLyon,5\nParis,7\n"
+	tmpl := `{{.Count}} {{sumBy "Amount" .}} {{maxBy "Amount" .}}{{range $city, $n := countBy "City" .}} {{$city}}={{$n}}{{end}}`
+	got := renderCSV(t, csv, tmpl)
+	if want := "3 22 10 Lyon=1 Paris=2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDataRows(t *testing.T) {
+	rows := []map[string]any{{"A": 1}, {"A": 2, totalField: true}}
+	got, err := dataRows("f", rows)
+	if err != nil || len(got) != 1 {
+		t.Errorf("dataRows = %v, %v; want the first row only", got, err)
+	}
+	if dataset(rows).Count() != 1 {
+		t.Errorf("Count = %d, want 1", dataset(rows).Count())
+	}
+	if _, err := dataRows("f", []int{1}); err == nil || !strings.HasPrefix(err.Error(), "f: ") {
+		t.Errorf("not rows: error = %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archive collects the outputs as entries of a single zip or tar (optionally gzipped) file.
+type archive struct {
+	f     *atomicFile
+	zip   *zip.Writer
+	tar   *tar.Writer
+	gz    *gzip.Writer
+	names map[string]bool
+	// modes are the permissions of the entries, when not 0o644
+	modes map[string]fs.FileMode
+}
+
+// openArchive creates the archive file, its format is given by the extension:
+// .zip, .tar, .tar.gz or .tgz.
+// If force is false and the file exists, an error is returned.
+func openArchive(fileName string, force bool) (*archive, error) {
+	lower := strings.ToLower(fileName)
+	if !strings.HasSuffix(lower, ".zip") && !strings.HasSuffix(lower, ".tar") &&
+		!strings.HasSuffix(lower, ".tar.gz") && !strings.HasSuffix(lower, ".tgz") {
+		return nil, fmt.Errorf("unknown archive format for %s (expected .zip, .tar, .tar.gz or .tgz)", fileName)
+	}
+	if _, err := os.Stat(fileName); err == nil && !force {
+		return nil, fmt.Errorf("archive %s already exists (use -force to overwrite)", fileName)
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+		return nil, fmt.Errorf("create directories: %w", err)
+	}
+	f, err := createAtomic(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("create archive: %w", err)
+	}
+	a := &archive{f: f, names: make(map[string]bool), modes: make(map[string]fs.FileMode)}
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		a.zip = zip.NewWriter(f)
+	case strings.HasSuffix(lower, ".tar"):
+		a.tar = tar.NewWriter(f)
+	default:
+		a.gz = gzip.NewWriter(f)
+		a.tar = tar.NewWriter(a.gz)
+	}
+	return a, nil
+}
+
+// entryName returns the name of the archive entry of the output file.
+func entryName(fileName string) string {
+	return strings.TrimLeft(path.Clean(filepath.ToSlash(fileName)), "/")
+}
+
+// setMode sets the permissions of the entry of the output file,
+// it must be called before the entry is closed.
+func (a *archive) setMode(fileName string, mode fs.FileMode) {
+	a.modes[entryName(fileName)] = mode
+}
+
+// entry returns a writer for a new entry of the archive.
+// The entry is added when the writer is closed.
+func (a *archive) entry(fileName string) (io.WriteCloser, error) {
+	name := entryName(fileName)
+	if name == "." || strings.HasPrefix(name, "../") {
+		return nil, fmt.Errorf("invalid archive entry name %s", fileName)
+	}
+	if a.names[name] {
+		return nil, fmt.Errorf("entry %s already in the archive", name)
+	}
+	a.names[name] = true
+	return &archiveEntry{archive: a, name: name}, nil
+}
+
+// finish completes and closes the archive, or discards it if the run failed (err != nil).
+// It returns the error of the run, or else the closing error.
+func (a *archive) finish(err error) error {
+	if err != nil {
+		a.f.Abort()
+		return err
+	}
+	if a.zip != nil {
+		err = a.zip.Close()
+	} else {
+		err = a.tar.Close()
+		if a.gz != nil && err == nil {
+			err = a.gz.Close()
+		}
+	}
+	if err != nil {
+		a.f.Abort()
+		return fmt.Errorf("close archive: %w", err)
+	}
+	if err := a.f.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return nil
+}
+
+// archiveEntry keeps the content of an entry in memory until it is closed.
+type archiveEntry struct {
+	bytes.Buffer
+	archive *archive
+	name    string
+}
+
+// Close writes the entry in the archive.
+func (e *archiveEntry) Close() error {
+	now := time.Now()
+	mode := fs.FileMode(0o644)
+	if m, ok := e.archive.modes[e.name]; ok {
+		mode = m
+	}
+	if e.archive.zip != nil {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: now}
+		hdr.SetMode(mode)
+		w, err := e.archive.zip.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(e.Bytes())
+		return err
+	}
+	hdr := &tar.Header{Name: e.name, Mode: int64(mode), Size: int64(e.Len()), ModTime: now, Typeflag: tar.TypeReg}
+	if err := e.archive.tar.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := e.archive.tar.Write(e.Bytes())
+	return err
+}
+
+// Abort drops the entry.
+func (e *archiveEntry) Abort() {
+	delete(e.archive.names, e.name)
+}
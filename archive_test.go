@@ -0,0 +1,166 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	want := map[string]string{"out/Ann.txt": "Hi Ann", "out/Bob.txt": "Hi Bob"}
+
+	zipName := filepath.Join(dir, "all.zip")
+	if err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", "out/{{.Name}}.txt", "--archive", zipName); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(zipName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != len(want) {
+		t.Errorf("%d zip entries, want %d", len(zr.File), len(want))
+	}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if string(data) != want[f.Name] {
+			t.Errorf("zip %s = %q, want %q", f.Name, data, want[f.Name])
+		}
+	}
+
+	tgzName := filepath.Join(dir, "all.tgz")
+	if err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", "out/{{.Name}}.txt", "--archive", tgzName); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(tgzName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if string(data) != want[hdr.Name] {
+			t.Errorf("tar %s = %q, want %q", hdr.Name, data, want[hdr.Name])
+		}
+		count++
+	}
+	if count != len(want) {
+		t.Errorf("%d tar entries, want %d", count, len(want))
+	}
+	// the outputs are not written on disk
+	if _, err := os.Stat("out"); err == nil {
+		t.Error("outputs written on disk")
+	}
+}
+
+func TestArchiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := openArchive(filepath.Join(dir, "out.rar"), false); err == nil {
+		t.Error("unknown archive format: no error")
+	}
+	a, err := openArchive(filepath.Join(dir, "out.zip"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../evil.txt", "."} {
+		if _, err := a.entry(name); err == nil {
+			t.Errorf("entry %q: no error", name)
+		}
+	}
+	if _, err := a.entry("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.entry("./a.txt"); err == nil {
+		t.Error("duplicate entry: no error")
+	}
+	if err := a.finish(os.ErrInvalid); err != os.ErrInvalid {
+		t.Errorf("finish = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.zip")); err == nil {
+		t.Error("failed archive kept")
+	}
+}
+
+func TestArchiveEntryMode(t *testing.T) {
+	for _, ext := range []string{".zip", ".tar"} {
+		fileName := filepath.Join(t.TempDir(), "out"+ext)
+		a, err := openArchive(fileName, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.setMode("bin/run.sh", 0o755)
+		for _, name := range []string{"a.txt", "bin/run.sh"} {
+			w, err := a.entry(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, name)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := a.finish(nil); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]fs.FileMode{"a.txt": 0o644, "bin/run.sh": 0o755}
+		got := map[string]fs.FileMode{}
+		if ext == ".zip" {
+			r, err := zip.OpenReader(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range r.File {
+				got[f.Name] = f.Mode().Perm()
+			}
+			r.Close()
+		} else {
+			f, err := os.Open(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := tar.NewReader(f)
+			for {
+				hdr, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[hdr.Name] = fs.FileMode(hdr.Mode).Perm()
+			}
+			f.Close()
+		}
+		for name, mode := range want {
+			if got[name] != mode {
+				t.Errorf("%s: mode of %s = %o, want %o", ext, name, got[name], mode)
+			}
+		}
+	}
+}
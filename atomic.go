@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// atomicFile is written in a temporary file, next to the output file,
+// renamed to the output file name only when closed successfully.
+// So readers never see a partial output.
+type atomicFile struct {
+	*os.File
+	name string
+}
+
+// createAtomic creates the temporary file for the output file name.
+// The output keeps the permissions of the file it replaces, if any,
+// else it is created with 0666 masked by the umask, like os.Create does.
+func createAtomic(fileName string) (*atomicFile, error) {
+	info, statErr := os.Stat(fileName)
+	dir, base := filepath.Dir(fileName), filepath.Base(fileName)
+	for range 100 {
+		tmp := filepath.Join(dir, "."+base+"."+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if statErr == nil {
+			if err := f.Chmod(info.Mode().Perm()); err != nil {
+				f.Close()
+				os.Remove(tmp)
+				return nil, err
+			}
+		}
+		return &atomicFile{File: f, name: fileName}, nil
+	}
+	return nil, fmt.Errorf("create temporary file for %s: too many attempts", fileName)
+}
+
+// copyFrom copies the content of the file fileName, if it exists, in the temporary file.
+// The output is then appended to this content.
+func (f *atomicFile) copyFrom(fileName string) error {
+	src, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(f.File, src)
+	return err
+}
+
+// Close closes the temporary file and renames it to the output file.
+func (f *atomicFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.name); err != nil {
+		os.Remove(f.File.Name())
+		return fmt.Errorf("rename output: %w", err)
+	}
+	return nil
+}
+
+// Abort closes and removes the temporary file, leaving the output untouched.
+func (f *atomicFile) Abort() {
+	f.File.Close()
+	os.Remove(f.File.Name())
+}
+
+// abort closes a writer after a failure, discarding the output when possible.
+func abort(w io.WriteCloser) {
+	if a, ok := w.(interface{ Abort() }); ok {
+		a.Abort()
+		return
+	}
+	w.Close()
+}
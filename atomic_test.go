@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "out.txt")
+	writeFile(t, name, "old")
+
+	f, err := createAtomic(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("partial")
+	if data, _ := os.ReadFile(name); string(data) != "old" {
+		t.Errorf("output changed before close: %q", data)
+	}
+	abort(f)
+	if data, _ := os.ReadFile(name); string(data) != "old" {
+		t.Errorf("output changed by abort: %q", data)
+	}
+
+	f, err = createAtomic(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("new")
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "new" {
+		t.Errorf("output = %q, want %q", data, "new")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	out := filepath.Join(dir, "log.txt")
+	writeFile(t, out, "start\n")
+	for range 2 {
+		if err := runCLI("-i", csv, "-t", "{{range .}}{{.Name}}\n{{end}}", "-o", out, "--append"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(out); string(data) != "start\nAnn\nAnn\n" {
+		t.Errorf("log.txt = %q", data)
+	}
+	if err := runCLI("-i", csv, "-t", "x", "-o", out); err == nil {
+		t.Error("existing output overwritten without --force or --append")
+	}
+}
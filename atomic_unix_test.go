@@ -0,0 +1,48 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCreateAtomicMode(t *testing.T) {
+	old := syscall.Umask(0o027)
+	defer syscall.Umask(old)
+	dir := t.TempDir()
+	create := func(name string) fs.FileMode {
+		t.Helper()
+		f, err := createAtomic(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode().Perm()
+	}
+	// a new file follows the umask
+	name := filepath.Join(dir, "new.txt")
+	if mode := create(name); mode != 0o640 {
+		t.Errorf("new file mode = %o, want 640", mode)
+	}
+	// a replaced file keeps its permissions
+	if err := os.Chmod(name, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if mode := create(name); mode != 0o755 {
+		t.Errorf("replaced file mode = %o, want 755", mode)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left in %s: %v", dir, entries)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// auditLog appends one JSON line per rendered output to the audit file.
+type auditLog struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// auditEntry records which rows and columns were rendered into which output.
+type auditEntry struct {
+	Time    string   `json:"time"`
+	Output  string   `json:"output"`
+	Sink    string   `json:"sink"`
+	Rows    []int    `json:"rows"`
+	Columns []string `json:"columns"`
+	Masked  bool     `json:"masked"`
+}
+
+// openAudit opens (or creates) the audit file in append mode.
+func openAudit(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &auditLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// close closes the audit file.
+func (l *auditLog) close() error {
+	return l.f.Close()
+}
+
+// outputSink returns the audit sink name for an output file name.
+func outputSink(fileName string) string {
+	if fileName == "-" {
+		return "stdout"
+	}
+	return "file"
+}
+
+// recordAudit appends an entry for the given output and rows, if auditing is enabled.
+// The sink tells where the output went ("file" or "stdout").
+func (a *app) recordAudit(output, sink string, rows []map[string]any) error {
+	if a.audit == nil || a.dryRun {
+		return nil
+	}
+	entry := auditEntry{
+		Time:    time.Now().Format(time.RFC3339),
+		Output:  output,
+		Sink:    sink,
+		Rows:    a.rowNumbers(rows),
+		Columns: a.headers,
+		Masked:  a.mask != nil,
+	}
+	if err := a.audit.enc.Encode(entry); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name,Mail\nAnn,a@x\nBob,b@x\n")
+	audit := filepath.Join(dir, "audit.jsonl")
+	for range 2 {
+		if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "{{.Name}}.txt"), "-f", "--audit", audit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []auditEntry
+	for s := bufio.NewScanner(f); s.Scan(); {
+		var e auditEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", s.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	// the log is appended to, one entry per output
+	if len(entries) != 4 {
+		t.Fatalf("%d audit entries, want 4", len(entries))
+	}
+	e := entries[1]
+	if e.Output != filepath.Join(dir, "Bob.txt") || e.Sink != "file" || !slices.Equal(e.Columns, []string{"Name", "Mail"}) || e.Masked {
+		t.Errorf("entry = %+v", e)
+	}
+	if info, err := os.Stat(audit); err == nil && info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("audit log mode = %o, want private", info.Mode().Perm())
+	}
+}
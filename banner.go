@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// commentStyle is how comments are written in a file format:
+// either a line prefix, or block start and end markers.
+type commentStyle struct {
+	line, start, end string
+}
+
+// commentStyles maps the output extensions to their comment style.
+var commentStyles = map[string]commentStyle{
+	".go": {line: "// "}, ".js": {line: "// "}, ".ts": {line: "// "}, ".c": {line: "// "},
+	".h": {line: "// "}, ".cpp": {line: "// "}, ".java": {line: "// "}, ".cs": {line: "// "},
+	".rs": {line: "// "}, ".swift": {line: "// "}, ".kt": {line: "// "}, ".php": {line: "// "},
+	".scss": {line: "// "},
+	".py":   {line: "# "}, ".sh": {line: "# "}, ".rb": {line: "# "}, ".pl": {line: "# "},
+	".yaml": {line: "# "}, ".yml": {line: "# "}, ".toml": {line: "# "}, ".conf": {line: "# "},
+	".properties": {line: "# "}, ".tf": {line: "# "}, ".r": {line: "# "}, ".mk": {line: "# "},
+	".tex": {line: "% "}, ".sty": {line: "% "}, ".cls": {line: "% "}, ".bib": {line: "% "},
+	".sql": {line: "-- "}, ".lua": {line: "-- "}, ".hs": {line: "-- "},
+	".ini": {line: "; "}, ".lisp": {line: "; "}, ".clj": {line: "; "},
+	".html": {start: "<!-- ", end: " -->"}, ".htm": {start: "<!-- ", end: " -->"},
+	".xml": {start: "<!-- ", end: " -->"}, ".svg": {start: "<!-- ", end: " -->"},
+	".md": {start: "<!-- ", end: " -->"}, ".vue": {start: "<!-- ", end: " -->"},
+	".css": {start: "/* ", end: " */"},
+}
+
+// comment returns the banner as a comment for the file name, ending with a newline.
+// It returns false if the comment style of the file is unknown.
+func comment(banner, fileName string) (string, bool) {
+	style, ok := commentStyles[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return "", false
+	}
+	banner = strings.TrimRight(banner, "\n")
+	if style.line == "" {
+		return style.start + banner + style.end + "\n", true
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(banner, "\n") {
+		b.WriteString(strings.TrimRight(style.line+line, " ") + "\n")
+	}
+	return b.String(), true
+}
+
+// bannerInserter returns a process function writing the comment at the start
+// of the output, after the first line if it is a shebang (#!) or an XML declaration,
+// and after the <?php opening tag.
+func bannerInserter(comment string) func(io.Reader, io.Writer) error {
+	return func(in io.Reader, out io.Writer) error {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		var head []byte
+		if bytes.HasPrefix(data, []byte("#!")) || bytes.HasPrefix(data, []byte("<?xml")) {
+			end := bytes.IndexByte(data, '\n') + 1
+			if end == 0 {
+				data = append(data, '\n')
+				end = len(data)
+			}
+			head, data = data[:end:end], data[end:]
+		}
+		if bytes.HasPrefix(data, []byte("<?php")) {
+			// the comment goes on its own line, after the tag
+			rest := bytes.TrimLeft(data[len("<?php"):], " \t")
+			rest = bytes.TrimPrefix(bytes.TrimPrefix(rest, []byte("\r")), []byte("\n"))
+			head = append(head, "<?php\n"...)
+			data = rest
+		}
+		for _, part := range [][]byte{head, []byte(comment), data} {
+			if _, err := out.Write(part); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBannerInserter(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "a = 1\n", "# banner\na = 1\n"},
+		{"shebang", "#!/bin/sh\necho\n", "#!/bin/sh\n# banner\necho\n"},
+		{"shebang only", "#!/bin/sh", "#!/bin/sh\n# banner\n"},
+		{"xml", "<?xml version=\"1.0\"?>\n<a/>\n", "<?xml version=\"1.0\"?>\n# banner\n<a/>\n"},
+		{"php", "<?php\necho 1;\n", "<?php\n# banner\necho 1;\n"},
+		{"php same line", "<?php echo 1;\n", "<?php\n# banner\necho 1;\n"},
+		{"php crlf", "<?php\r\necho 1;\r\n", "<?php\n# banner\necho 1;\r\n"},
+		{"php shebang", "#!/usr/bin/env php\n<?php\necho 1;\n", "#!/usr/bin/env php\n<?php\n# banner\necho 1;\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := bannerInserter("# banner\n")(strings.NewReader(tt.in), &out); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+func TestComment(t *testing.T) {
+	tests := []struct {
+		fileName, want string
+		ok             bool
+	}{
+		{"a.go", "// one\n//\n// two\n", true},
+		{"a.php", "// one\n//\n// two\n", true},
+		{"a.html", "<!-- one\n\ntwo -->\n", true},
+		{"a.unknown", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := comment("one\n\ntwo\n", tt.fileName)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("comment(%s) = %q, %v, want %q, %v", tt.fileName, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBanner(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "data.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	if err := runCLI("-i", csv, "-t", "name: {{.Name}}\n", "-o", filepath.Join(dir, "{{.Name}}.yaml"),
+		"--banner", "Generated from {{sourceFile}}, do not edit"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "Ann.yaml"))
+	if want := "# Generated from " + csv + ", do not edit\nname: Ann\n"; string(data) != want {
+		t.Errorf("Ann.yaml = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// changedWriter keeps the rendered output in memory and, when closed,
+// writes it only if it differs from the content of the existing file.
+type changedWriter struct {
+	bytes.Buffer
+	name  string
+	force bool
+	// unchanged is set by Close when the existing file had the same content
+	unchanged bool
+}
+
+// Close compares the rendered output with the existing file and writes it if needed.
+// An existing file with a different content is only overwritten with force.
+func (w *changedWriter) Close() error {
+	old, err := os.ReadFile(w.name)
+	if err == nil && bytes.Equal(old, w.Bytes()) {
+		w.unchanged = true
+		return nil
+	}
+	if err == nil && !w.force {
+		return fmt.Errorf("output file %s already exists and changed (use -force to overwrite)", w.name)
+	}
+	f, err := createAtomic(w.name)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	if _, err := f.Write(w.Bytes()); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
+
+// unchanged tells whether a closed output was left untouched by --if-changed.
+// The converting writers (see --postprocess, --out-encoding) are looked through.
+func unchanged(w io.WriteCloser) bool {
+	for {
+		u, ok := w.(interface{ underlying() io.WriteCloser })
+		if !ok {
+			break
+		}
+		w = u.underlying()
+	}
+	c, ok := w.(*changedWriter)
+	return ok && c.unchanged
+}
+
+// changeSummary prints the number of updated and unchanged files of an --if-changed run.
+func (a *app) changeSummary() {
+	if a.ifChanged && !a.dryRun {
+		a.info("%d files updated, %d unchanged\n", a.updated, a.unchanged)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	out := filepath.Join(dir, "{{.Name}}.txt")
+	if err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", out); err != nil {
+		t.Fatal(err)
+	}
+	ann := filepath.Join(dir, "Ann.txt")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(ann, past, past); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "Bob.txt"), "edited")
+
+	// Bob changed: not overwritten without --force
+	if err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", out, "--if-changed"); err == nil {
+		t.Error("changed file overwritten without --force")
+	}
+	if err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", out, "--if-changed", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(ann); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("unchanged Ann.txt rewritten")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "Bob.txt")); string(data) != "Hi Bob" {
+		t.Errorf("Bob.txt = %q, want %q", data, "Hi Bob")
+	}
+}
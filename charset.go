@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/unicode/norm"
+)
+
+// asciiReplacements are the transliterations of the characters that are not
+// a letter followed by combining marks.
+var asciiReplacements = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'þ': "th", 'Þ': "Th", 'ð': "d", 'Ð': "D",
+	'‘': "'", '’': "'", '‚': "'", '“': `"`, '”': `"`, '„': `"`, '«': `"`, '»': `"`,
+	'–': "-", '—': "-", '‐': "-", '…': "...", '•': "*", '€': "EUR", '£': "GBP",
+	' ': " ", ' ': " ", '×': "x",
+}
+
+// transliterate replaces the non ASCII characters by their closest ASCII form:
+// the accents are removed and some characters are spelled out (ß gives ss).
+// The characters without transliteration are kept.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// drop the combining marks (accents)
+		default:
+			if repl, ok := asciiReplacements[r]; ok {
+				b.WriteString(repl)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+// charsetChecker returns a process function failing if the output contains characters
+// outside the charset (ascii, or any encoding name like latin1), after transliteration
+// to ASCII if requested.
+func charsetChecker(charset string, enc encoding.Encoding, ascii bool) func(io.Reader, io.Writer) error {
+	return func(in io.Reader, out io.Writer) error {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		text := string(data)
+		if ascii {
+			text = transliterate(text)
+		}
+		var bad []string
+		line := 1
+		for _, r := range text {
+			if r == '\n' {
+				line++
+			}
+			if !inCharset(r, enc) {
+				bad = append(bad, fmt.Sprintf("%q (line %d)", r, line))
+				if len(bad) == maxReportedOffsets {
+					bad = append(bad, "...")
+					break
+				}
+			}
+		}
+		if len(bad) > 0 {
+			return fmt.Errorf("characters outside %s: %s", charset, strings.Join(bad, ", "))
+		}
+		_, err = io.WriteString(out, text)
+		return err
+	}
+}
+
+// inCharset tells whether r is in the encoding (ASCII if enc is nil).
+func inCharset(r rune, enc encoding.Encoding) bool {
+	if enc == nil {
+		return r < utf8.RuneSelf
+	}
+	_, err := enc.NewEncoder().String(string(r))
+	return err == nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func Example_transliterate() {
+	fmt.Println(transliterate("Crème brûlée — “Straße” 5 €"))
+	// Output: Creme brulee - "Strasse" 5 EUR
+}
+
+func TestCharsetChecker(t *testing.T) {
+	tests := []struct {
+		text    string
+		charset string
+		enc     encoding.Encoding
+		ascii   bool
+		want    string
+		wantErr string
+	}{
+		{"Café", "ascii", nil, true, "Cafe", ""},
+		{"Café", "latin1", charmap.ISO8859_1, false, "Café", ""},
+		{"ok\nCafé 漢", "ascii", nil, false, "", `characters outside ascii: 'é' (line 2), '漢' (line 2)`},
+		{"漢", "latin1", charmap.ISO8859_1, true, "", `characters outside latin1: '漢' (line 1)`},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		err := charsetChecker(tt.charset, tt.enc, tt.ascii)(strings.NewReader(tt.text), &out)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%q: error %v, want %s", tt.text, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || out.String() != tt.want {
+			t.Errorf("%q: got %q, %v, want %q", tt.text, out.String(), err, tt.want)
+		}
+	}
+}
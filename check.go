@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// checkTemplates statically verifies that the fields used in the templates
+// (content, output name, partials, tree files and skip-if conditions) exist,
+// and reports all unknown fields at once. Nothing is rendered.
+// Only the first field of a chain is checked (.address.city checks address),
+// for the fields of the dot and of $; the library templates are not checked.
+// The mustache and pongo2 templates can not be checked.
+func (a *app) checkTemplates(funcs template.FuncMap) error {
+	if a.engine != "go" {
+		return fmt.Errorf("--check is only supported with the go engine, not %s", a.engine)
+	}
+	known := a.knownFields()
+	skip := make(map[string]bool, len(a.library))
+	for _, t := range a.library {
+		skip[t.name] = true
+	}
+	var trees []*parse.Tree
+	if info, err := os.Stat(a.templatePath); err == nil && info.IsDir() {
+		files, err := a.loadTree(a.templatePath, funcs)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			trees = append(trees, templateTrees(file.name)...)
+			trees = append(trees, templateTrees(file.skipIf)...)
+			trees = append(trees, templateTrees(file.content)...)
+		}
+	} else {
+		contentTmpl, err := a.parseTemplate(funcs)
+		if err != nil {
+			return err
+		}
+		trees = append(trees, templateTrees(contentTmpl)...)
+	}
+	if a.perRow() {
+		nameTmpl, err := a.parseName("outfile", a.outPath, funcs)
+		if err != nil {
+			return fmt.Errorf("parse output template: %w", err)
+		}
+		trees = append(trees, templateTrees(nameTmpl)...)
+	}
+
+	var unknown int
+	seen := make(map[*parse.Tree]bool)
+	for _, tree := range trees {
+		if tree == nil || tree.Root == nil || seen[tree] || skip[tree.ParseName] {
+			continue
+		}
+		seen[tree] = true
+		walkFields(tree.Root, func(n parse.Node, field string) {
+			if !known[field] {
+				unknown++
+				location, _ := tree.ErrorContext(n)
+				fmt.Fprintf(os.Stderr, "  %s: unknown field %q\n", location, field)
+			}
+		})
+	}
+	if unknown > 0 {
+		return fmt.Errorf("%d unknown fields in the templates", unknown)
+	}
+	a.info("templates checked: all fields exist\n")
+	return nil
+}
+
+// knownFields returns the names of the fields available in the rows (and groups).
+func (a *app) knownFields() map[string]bool {
+	known := map[string]bool{a.counter: true}
+	for _, h := range a.headers {
+		known[h] = true
+		if !a.noNested {
+			first, _, _ := strings.Cut(h, ".")
+			known[first] = true
+		}
+	}
+	for key := range a.vars {
+		known[key] = true
+	}
+	if a.groupBy != "" {
+		known[a.localCounter] = true
+		for _, f := range []string{"Key", "Rows", "First"} {
+			known[f] = true
+		}
+	}
+	if a.chunk > 0 {
+		known[a.localCounter] = true
+	}
+	if len(a.totals) > 0 {
+		known[totalField] = true
+	}
+	if a.groupBy == "" {
+		for _, f := range neighborFields {
+			known[f] = true
+		}
+	}
+	// The fields of the pages returned by paginate (and of the single file mode dataset)
+	for _, f := range []string{"Number", "Total", "Rows", "First", "Last", "Count", "IsFirst", "IsLast"} {
+		known[f] = true
+	}
+	return known
+}
+
+// templateTrees returns the parse trees of all templates associated with t
+// (a text or html template, possibly nil).
+func templateTrees(t any) []*parse.Tree {
+	var trees []*parse.Tree
+	switch t := t.(type) {
+	case *template.Template:
+		if t == nil {
+			return nil
+		}
+		for _, tt := range t.Templates() {
+			trees = append(trees, tt.Tree)
+		}
+	case *htmltemplate.Template:
+		if t == nil {
+			return nil
+		}
+		for _, tt := range t.Templates() {
+			trees = append(trees, tt.Tree)
+		}
+	}
+	return trees
+}
+
+// walkFields calls fn for the first field of every field chain of the dot or of $.
+func walkFields(node parse.Node, fn func(parse.Node, string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkFields(c, fn)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walkFields(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkFields(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkFields(n.Node, fn)
+	case *parse.FieldNode:
+		fn(n, n.Ident[0])
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			fn(n, n.Ident[1])
+		}
+	}
+}
+
+// walkBranch walks the pipeline and both lists of an if, range or with node.
+func walkBranch(n *parse.BranchNode, fn func(parse.Node, string)) {
+	walkFields(n.Pipe, fn)
+	walkFields(n.List, fn)
+	walkFields(n.ElseList, fn)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckTemplates(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name,address.city\nAnn,Paris\n")
+	out := filepath.Join(dir, "{{.Name}}.txt")
+	tests := []struct {
+		tmpl string
+		out  string
+		ok   bool
+	}{
+		{"{{.Name}} {{.address.city}} {{._index_}}", out, true},
+		{"{{$.Name}}{{if .Age}}{{end}}", out, false},
+		{"{{range .}}{{.Name}}{{end}}", filepath.Join(dir, "{{.Nmae}}.txt"), false},
+	}
+	for _, tt := range tests {
+		err := runCLI("-i", csv, "-t", tt.tmpl, "-o", tt.out, "--check")
+		if (err == nil) != tt.ok {
+			t.Errorf("check %q -> %q: error %v", tt.tmpl, tt.out, err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.txt")); len(matches) > 0 {
+		t.Errorf("--check wrote %v", matches)
+	}
+}
+
+func TestCheckEngines(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	for _, engine := range []string{"mustache", "pongo2"} {
+		err := runCLI("-i", csv, "-t", "{{Nmae}}", "-o", filepath.Join(dir, "out.txt"), "--check", "--engine", engine)
+		if err == nil || err.Error() != "--check is only supported with the go engine, not "+engine {
+			t.Errorf("%s: err = %v", engine, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// threshold is a classify step: the values from min (included) get the label.
+type threshold struct {
+	min   float64
+	label string
+}
+
+// parseThresholds parses the classify steps of the form "0:red,50:orange,80:green".
+func parseThresholds(spec string) ([]threshold, error) {
+	var steps []threshold
+	for _, part := range strings.Split(spec, ",") {
+		bound, label, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("classify: invalid step %q (expected min:label)", part)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(bound), 64)
+		if err != nil {
+			return nil, fmt.Errorf("classify: invalid threshold %q", bound)
+		}
+		steps = append(steps, threshold{min: f, label: strings.TrimSpace(label)})
+	}
+	slices.SortStableFunc(steps, func(a, b threshold) int { return cmp.Compare(a.min, b.min) })
+	return steps, nil
+}
+
+// classify returns the label of the highest threshold not above the value:
+// classify .Score "0:red,50:orange,80:green" gives orange for 65.
+// A value below all thresholds or empty gives an empty string.
+func classify(v any, spec string) (string, error) {
+	steps, err := parseThresholds(spec)
+	if err != nil {
+		return "", err
+	}
+	if v == nil || v == "" {
+		return "", nil
+	}
+	f, ok := toNumber(v, true)
+	if !ok {
+		return "", fmt.Errorf("classify: %v is not a number", v)
+	}
+	label := ""
+	for _, s := range steps {
+		if f < s.min {
+			break
+		}
+		label = s.label
+	}
+	return label, nil
+}
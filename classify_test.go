@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_classify() {
+	for _, score := range []any{-5, 0, "65", 80.5, ""} {
+		label, _ := classify(score, "80:green, 0:red, 50:orange")
+		fmt.Printf("%v=%q ", score, label)
+	}
+	// Output: -5="" 0="red" 65="orange" 80.5="green" =""
+}
+
+func TestClassifyErrors(t *testing.T) {
+	for _, spec := range []string{"0-red", "low:red"} {
+		if _, err := classify(1, spec); err == nil {
+			t.Errorf("classify(%q): no error", spec)
+		}
+	}
+	if _, err := classify("n/a", "0:red"); err == nil {
+		t.Error("not a number: no error")
+	}
+}
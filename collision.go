@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// output is a file written in per-row mode with the units rendered into it.
+type output struct {
+	name  string
+	units []unit
+	// content is the rendered content (see --name-by-hash), if not nil
+	content []byte
+}
+
+// resolveCollisions applies the --on-collision policy to the outputs, in order,
+// when several units render to the same file name:
+//   - "error" fails,
+//   - "suffix" adds -2, -3, ... to the name (before the extension),
+//   - "append" renders all the units in a single file.
+//
+// Without a policy the outputs are kept as they are (the later ones need --force).
+func resolveCollisions(outputs []output, policy string) ([]output, error) {
+	if policy == "" {
+		return outputs, nil
+	}
+	result := make([]output, 0, len(outputs))
+	index := make(map[string]int, len(outputs))
+	for _, o := range outputs {
+		i, seen := index[o.name]
+		if !seen {
+			index[o.name] = len(result)
+			result = append(result, o)
+			continue
+		}
+		switch policy {
+		case "error":
+			return nil, fmt.Errorf("%s and %s both render to %s", result[i].units[0].name, o.units[0].name, o.name)
+		case "append":
+			result[i].units = append(result[i].units, o.units...)
+		case "suffix":
+			ext := filepath.Ext(o.name)
+			base := strings.TrimSuffix(o.name, ext)
+			name := o.name
+			for n := 2; seen; n++ {
+				name = fmt.Sprintf("%s-%d%s", base, n, ext)
+				_, seen = index[name]
+			}
+			index[name] = len(result)
+			result = append(result, output{name: name, units: o.units})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveCollisions(t *testing.T) {
+	outputs := func() []output {
+		return []output{
+			{name: "a.txt", units: []unit{{name: "row 1"}}},
+			{name: "b.txt", units: []unit{{name: "row 2"}}},
+			{name: "a.txt", units: []unit{{name: "row 3"}}},
+			{name: "a-2.txt", units: []unit{{name: "row 4"}}},
+			{name: "a.txt", units: []unit{{name: "row 5"}}},
+		}
+	}
+	names := func(outputs []output) map[string][]string {
+		m := make(map[string][]string)
+		for _, o := range outputs {
+			for _, u := range o.units {
+				m[o.name] = append(m[o.name], u.name)
+			}
+		}
+		return m
+	}
+	tests := []struct {
+		policy  string
+		count   int
+		want    map[string][]string
+		wantErr bool
+	}{
+		{"", 5, map[string][]string{"a.txt": {"row 1", "row 3", "row 5"}, "b.txt": {"row 2"}, "a-2.txt": {"row 4"}}, false},
+		{"append", 3, map[string][]string{"a.txt": {"row 1", "row 3", "row 5"}, "b.txt": {"row 2"}, "a-2.txt": {"row 4"}}, false},
+		{"suffix", 5, map[string][]string{"a.txt": {"row 1"}, "b.txt": {"row 2"}, "a-2.txt": {"row 3"}, "a-2-2.txt": {"row 4"}, "a-3.txt": {"row 5"}}, false},
+		{"error", 0, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := resolveCollisions(outputs(), tt.policy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, want error %v", tt.policy, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (len(got) != tt.count || !reflect.DeepEqual(names(got), tt.want)) {
+			t.Errorf("%q: got %d outputs %v, want %d %v", tt.policy, len(got), names(got), tt.count, tt.want)
+		}
+	}
+}
+
+func TestOnCollisionSuffix(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "City,Name\nParis,Ann\nLyon,Bob\nParis,Eve\n")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "out", "{{.City}}.txt"), "--on-collision", "suffix"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Paris.txt": "Ann", "Lyon.txt": "Bob", "Paris-2.txt": "Eve"}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the config file names looked for in the current directory
+// when --profile is used without --config.
+var configFiles = []string{"csvplate.toml", "csvplate.yaml", "csvplate.yml"}
+
+// config is the content of a config file: named profiles, each setting
+// options by their long flag name. Example csvplate.yaml:
+//
+//	profiles:
+//	  invoices:
+//	    csv: data/invoices.csv
+//	    template: templates/invoice.tmpl
+//	    out: "out/{{.Number}}.txt"
+//	    csv-sep: ";"
+//	    force: true
+//	    set:
+//	      company: ACME
+type config struct {
+	Profiles map[string]map[string]any `yaml:"profiles" toml:"profiles"`
+}
+
+// findConfig returns the first config file of the current directory.
+func findConfig() (string, error) {
+	for _, name := range configFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no config file found (%s)", strings.Join(configFiles, ", "))
+}
+
+// loadProfile reads the named profile of the config file (TOML or YAML, by extension).
+func loadProfile(path, name string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	var c config
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &c)
+	} else {
+		err = yaml.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("no profile %q in %s (available: %s)", name, path, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// applyProfile sets the flags of the profile, except the ones given on the command line.
+// A list sets a repeatable flag several times, a map sets key=value pairs (for --set).
+func applyProfile(flags *pflag.FlagSet, profile map[string]any) error {
+	for name, value := range profile {
+		flag := flags.Lookup(name)
+		if flag == nil || name == "config" || name == "profile" {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if flag.Changed {
+			continue
+		}
+		var values []string
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				values = append(values, fmt.Sprint(item))
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				values = append(values, k+"="+fmt.Sprint(v[k]))
+			}
+		case nil:
+			return fmt.Errorf("option %q has no value", name)
+		default:
+			values = []string{fmt.Sprint(v)}
+		}
+		for _, v := range values {
+			if err := flags.Set(name, v); err != nil {
+				return fmt.Errorf("option %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// useProfile applies the profile of the config file (found if configPath is empty).
+func useProfile(flags *pflag.FlagSet, configPath, profile string) error {
+	if profile == "" {
+		if configPath != "" {
+			return errors.New("--config needs a --profile")
+		}
+		return nil
+	}
+	if configPath == "" {
+		var err error
+		if configPath, err = findConfig(); err != nil {
+			return err
+		}
+	}
+	p, err := loadProfile(configPath, profile)
+	if err != nil {
+		return err
+	}
+	return applyProfile(flags, p)
+}
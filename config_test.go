@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyProfile(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	sep := flags.String("csv-sep", ",", "")
+	force := flags.Bool("force", false, "")
+	set := flags.StringArray("set", nil, "")
+	out := flags.String("out", "", "")
+	if err := flags.Parse([]string{"--out", "cli.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	profile := map[string]any{
+		"csv-sep": ";",
+		"force":   true,
+		"set":     map[string]any{"b": 2, "a": "x"},
+		"out":     "profile.txt",
+	}
+	if err := applyProfile(flags, profile); err != nil {
+		t.Fatal(err)
+	}
+	if *sep != ";" || !*force || len(*set) != 2 || (*set)[0] != "a=x" || (*set)[1] != "b=2" {
+		t.Errorf("flags = %q %v %q", *sep, *force, *set)
+	}
+	if *out != "cli.txt" {
+		t.Errorf("the command line --out was overridden by %q", *out)
+	}
+	if err := applyProfile(flags, map[string]any{"unknown": 1}); err == nil {
+		t.Error("unknown option: no error")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name;Age\nAnn;30\n")
+	out := filepath.Join(dir, "out.txt")
+	cfg := filepath.Join(dir, "csvplate.toml")
+	writeFile(t, cfg, "[profiles.people]\ncsv = '"+csv+"'\ncsv-sep = ';'\ntemplate = '{{range .}}{{.Name}} {{.Age}}{{end}}'\nout = '"+out+"'\n")
+	if err := runCLI("--config", cfg, "--profile", "people"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "Ann 30" {
+		t.Errorf("out.txt = %q", data)
+	}
+	if _, err := loadProfile(cfg, "other"); err == nil || err.Error() != `no profile "other" in `+cfg+" (available: people)" {
+		t.Errorf("loadProfile = %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// toDecimal converts a number or a numeric string (a cell or a float) to a decimal.
+// Floats are converted through their shortest representation, so 0.1 is exactly 0.1.
+func toDecimal(function string, v any) (decimal.Decimal, error) {
+	switch n := v.(type) {
+	case decimal.Decimal:
+		return n, nil
+	case int:
+		return decimal.NewFromInt(int64(n)), nil
+	case int64:
+		return decimal.NewFromInt(n), nil
+	case float64:
+		return decimal.NewFromFloat(n), nil
+	case string:
+		d, err := decimal.NewFromString(strings.TrimSpace(n))
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("%s: %q is not a number", function, n)
+		}
+		return d, nil
+	case fmt.Stringer:
+		return toDecimal(function, n.String())
+	default:
+		return decimal.Zero, fmt.Errorf("%s: %v (%T) is not a number", function, v, v)
+	}
+}
+
+// toDecimals converts all values to decimals.
+func toDecimals(function string, values []any) ([]decimal.Decimal, error) {
+	ds := make([]decimal.Decimal, len(values))
+	for i, v := range values {
+		d, err := toDecimal(function, v)
+		if err != nil {
+			return nil, err
+		}
+		ds[i] = d
+	}
+	return ds, nil
+}
+
+// decAdd returns the exact sum of the values.
+func decAdd(values ...any) (decimal.Decimal, error) {
+	ds, err := toDecimals("decAdd", values)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.Sum(decimal.Zero, ds...), nil
+}
+
+// decSub returns a - b.
+func decSub(a, b any) (decimal.Decimal, error) {
+	ds, err := toDecimals("decSub", []any{a, b})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ds[0].Sub(ds[1]), nil
+}
+
+// decMul returns the exact product of the values.
+func decMul(values ...any) (decimal.Decimal, error) {
+	ds, err := toDecimals("decMul", values)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	product := decimal.NewFromInt(1)
+	for _, d := range ds {
+		product = product.Mul(d)
+	}
+	return product, nil
+}
+
+// decDiv returns a / b, with 16 decimal places at most.
+func decDiv(a, b any) (decimal.Decimal, error) {
+	ds, err := toDecimals("decDiv", []any{a, b})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if ds[1].IsZero() {
+		return decimal.Zero, errors.New("decDiv: division by zero")
+	}
+	return ds[0].Div(ds[1]), nil
+}
+
+// decRound rounds the value to places decimal places (half away from zero) and keeps
+// the trailing zeros: decRound 2 "3.1" gives 3.10.
+func decRound(places int, v any) (string, error) {
+	d, err := toDecimal("decRound", v)
+	if err != nil {
+		return "", err
+	}
+	if places < 0 {
+		return "", fmt.Errorf("decRound: places must be positive, got %d", places)
+	}
+	return d.StringFixed(int32(places)), nil
+}
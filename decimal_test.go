@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_decimal() {
+	sum, _ := decAdd(0.1, "0.2", 3)
+	product, _ := decMul("19.99", 3)
+	quotient, _ := decDiv(1, 3)
+	diff, _ := decSub("1.10", 0.1)
+	rounded, _ := decRound(2, "2.675")
+	fmt.Println(sum, product, quotient, diff, rounded)
+	// Output: 3.3 59.97 0.3333333333333333 1 2.68
+}
+
+func TestDecimalErrors(t *testing.T) {
+	if _, err := decAdd(1, "one"); err == nil || err.Error() != `decAdd: "one" is not a number` {
+		t.Errorf("decAdd = %v", err)
+	}
+	if _, err := decDiv(1, "0.0"); err == nil {
+		t.Error("division by zero: no error")
+	}
+	if _, err := decRound(-1, 1); err == nil {
+		t.Error("negative places: no error")
+	}
+	if _, err := decSub(true, 1); err == nil {
+		t.Error("bool: no error")
+	}
+}
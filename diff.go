@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffTokenRe splits a text in words and whitespace runs for the word diffs.
+var diffTokenRe = regexp.MustCompile(`\s+|[^\s]+`)
+
+// diffText returns the differences between the old and new texts:
+//   - "inline": word diff with [-removed-] and {+added+} markers,
+//   - "html": word diff with <del> and <ins> tags (the text is escaped),
+//   - "unified": line diff in the unified format, without file headers.
+func diffText(mode string, oldText, newText any) (string, error) {
+	a, b := fmt.Sprint(oldText), fmt.Sprint(newText)
+	switch mode {
+	case "inline":
+		return wordDiff(a, b, "[-", "-]", "{+", "+}", func(s string) string { return s }), nil
+	case "html":
+		return wordDiff(a, b, "<del>", "</del>", "<ins>", "</ins>", html.EscapeString), nil
+	case "unified":
+		return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:       difflib.SplitLines(a),
+			B:       difflib.SplitLines(b),
+			Context: 3,
+		})
+	default:
+		return "", fmt.Errorf("diffText: unknown mode %q (expected inline, html or unified)", mode)
+	}
+}
+
+// wordDiff returns the new text with the removed and added words surrounded by the markers.
+func wordDiff(a, b, delOpen, delClose, insOpen, insClose string, escape func(string) string) string {
+	ta, tb := diffTokenRe.FindAllString(a, -1), diffTokenRe.FindAllString(b, -1)
+	var out strings.Builder
+	for _, op := range difflib.NewMatcher(ta, tb).GetOpCodes() {
+		removed := escape(strings.Join(ta[op.I1:op.I2], ""))
+		added := escape(strings.Join(tb[op.J1:op.J2], ""))
+		switch op.Tag {
+		case 'e':
+			out.WriteString(added)
+		case 'd':
+			out.WriteString(delOpen + removed + delClose)
+		case 'i':
+			out.WriteString(insOpen + added + insClose)
+		case 'r':
+			out.WriteString(delOpen + removed + delClose + insOpen + added + insClose)
+		}
+	}
+	return out.String()
+}
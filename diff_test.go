@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestDiffText(t *testing.T) {
+	tests := []struct {
+		mode, old, new, want string
+	}{
+		{"inline", "the quick fox", "the slow fox", "the [-quick-]{+slow+} fox"},
+		{"inline", "a b", "a b c", "a b{+ c+}"},
+		{"html", "x <y>", "x", "x<del> &lt;y&gt;</del>"},
+		{"unified", "a\nb", "a\nc", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{"inline", "same", "same", "same"},
+	}
+	for _, tt := range tests {
+		got, err := diffText(tt.mode, tt.old, tt.new)
+		if err != nil || got != tt.want {
+			t.Errorf("diffText(%q, %q, %q) = %q, %v; want %q", tt.mode, tt.old, tt.new, got, err, tt.want)
+		}
+	}
+	if _, err := diffText("side", "a", "b"); err == nil {
+		t.Error("unknown mode: no error")
+	}
+}
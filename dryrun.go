@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// discard is the output of dry runs: everything written to it is dropped.
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+func (discard) Close() error                { return nil }
+
+// savedHeader returns the header of the list of the generated files.
+func (a *app) savedHeader() string {
+	if a.dryRun {
+		return "results would be saved in:"
+	}
+	return "results saved in:"
+}
+
+// saved reports a generated file, once its writer w is closed.
+// In dry-run mode the files that already exist are marked as overwritten,
+// and with --if-changed the files left untouched are marked as unchanged.
+func (a *app) saved(fileName string, w io.WriteCloser) {
+	var mark string
+	switch {
+	case a.dryRun:
+		if _, err := os.Stat(fileName); err == nil {
+			mark = " (overwritten)"
+		}
+	case unchanged(w):
+		a.unchanged++
+		mark = " (unchanged)"
+	default:
+		a.updated++
+	}
+	a.generated(fileName, !unchanged(w))
+	if !a.progress {
+		a.info("%s%s\n", fileName, mark)
+	}
+}
+
+// renderFailed handles a rendering error: in dry-run mode it is reported and
+// counted so the run can continue, else it is returned.
+func (a *app) renderFailed(err error, count *int) error {
+	if !a.dryRun {
+		return err
+	}
+	*count++
+	fmt.Fprintf(os.Stderr, "  %v\n", err)
+	return nil
+}
+
+// runErrors returns the error summarizing the files that were not written.
+func runErrors(numErrors, renderErrors int) error {
+	if numErrors > 0 {
+		return fmt.Errorf("%d %w.", numErrors, errNotOverwritten)
+	}
+	if renderErrors > 0 {
+		return fmt.Errorf("%d %w", renderErrors, errRenderErrors)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	out := filepath.Join(dir, "{{.Name}}.txt")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", out, "--dry-run"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dry run wrote files: %v", entries)
+	}
+	// the rendering errors are all counted, not only the first one
+	err := runCLI("-i", csv, "-t", "{{.Name.Missing}}", "-o", out, "--dry-run")
+	if err == nil || err.Error() != "2 files could not be rendered" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if err := runErrors(0, 0); err != nil {
+		t.Errorf("runErrors(0, 0) = %v", err)
+	}
+	if err := runErrors(1, 2); err == nil || err.Error() != "1 files not overwritten." {
+		t.Errorf("runErrors(1, 2) = %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// referenceDate returns the optional reference date of age and humanDuration, today by default.
+func referenceDate(function string, ref []any) (time.Time, error) {
+	switch len(ref) {
+	case 0:
+		return time.Now(), nil
+	case 1:
+		return toDate(function, ref[0])
+	default:
+		return time.Time{}, fmt.Errorf("%s: too many arguments", function)
+	}
+}
+
+// calendarDiff returns the number of whole years, months and days from a to b (a before b).
+// Adding months to the 31st ends at the last day of shorter months.
+func calendarDiff(a, b time.Time) (years, months, days int) {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	total := (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+	if b.Day() < a.Day() && b.Day() != daysIn(b.Year(), b.Month()) {
+		total--
+	}
+	// the date total months after a, at most at the end of its month
+	anchor := time.Date(a.Year(), a.Month()+time.Month(total), 1, 0, 0, 0, 0, time.UTC)
+	anchor = anchor.AddDate(0, 0, min(a.Day(), daysIn(anchor.Year(), anchor.Month()))-1)
+	return total / 12, total % 12, int(b.Sub(anchor).Hours()) / 24
+}
+
+// daysIn returns the number of days of the month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// ageYears returns the age in whole years at the reference date (today by default)
+// of someone born at the birthdate: age .Birth or age .Birth "2026-01-01".
+func ageYears(birthdate any, ref ...any) (int, error) {
+	birth, err := toDate("age", birthdate)
+	if err != nil {
+		return 0, err
+	}
+	at, err := referenceDate("age", ref)
+	if err != nil {
+		return 0, err
+	}
+	if at.Before(birth) {
+		return 0, errors.New("age: the birthdate is after the reference date")
+	}
+	years, _, _ := calendarDiff(birth, at)
+	return years, nil
+}
+
+// humanDuration returns the time from the date to the reference date (today by default)
+// in words, with its two largest units: "3 years 2 months", "1 month 5 days".
+func humanDuration(date any, ref ...any) (string, error) {
+	from, err := toDate("humanDuration", date)
+	if err != nil {
+		return "", err
+	}
+	to, err := referenceDate("humanDuration", ref)
+	if err != nil {
+		return "", err
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	years, months, days := calendarDiff(from, to)
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		unit string
+	}{{years, "year"}, {months, "month"}, {days, "day"}} {
+		if p.n == 0 || len(parts) == 2 {
+			continue
+		}
+		if p.n > 1 {
+			p.unit += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", p.n, p.unit))
+	}
+	if len(parts) == 0 {
+		return "0 days", nil
+	}
+	return strings.Join(parts, " "), nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		birth, at string
+		want      int
+	}{
+		{"1990-05-15", "2026-05-14", 35},
+		{"1990-05-15", "2026-05-15", 36},
+		{"2000-02-29", "2025-02-28", 25},
+	}
+	for _, tt := range tests {
+		if got, err := ageYears(tt.birth, tt.at); err != nil || got != tt.want {
+			t.Errorf("age %s at %s = %d, %v, want %d", tt.birth, tt.at, got, err, tt.want)
+		}
+	}
+	if _, err := ageYears("2030-01-01", "2026-01-01"); err == nil {
+		t.Error("birth after the reference: no error")
+	}
+	if _, err := ageYears("2000-01-01", "2026-01-01", "2027-01-01"); err == nil {
+		t.Error("two reference dates: no error")
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		from, to, want string
+	}{
+		{"2023-01-10", "2026-03-15", "3 years 2 months"},
+		{"2026-01-31", "2026-02-28", "1 month"},
+		{"2026-03-15", "2026-01-10", "2 months 5 days"},
+		{"2026-01-10", "2026-01-10", "0 days"},
+		{"2025-01-01", "2026-01-02", "1 year 1 day"},
+	}
+	for _, tt := range tests {
+		if got, err := humanDuration(tt.from, tt.to); err != nil || got != tt.want {
+			t.Errorf("humanDuration(%s, %s) = %q, %v, want %q", tt.from, tt.to, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encrypter wraps an output writer so that everything written to it is encrypted.
+// Closing the returned writer flushes the encrypted stream and closes the wrapped one.
+type encrypter func(io.WriteCloser) (io.WriteCloser, error)
+
+// newEncrypter parses the --encrypt-out value.
+// The value is "age:<recipients file>" or "gpg:<recipient>".
+func newEncrypter(spec string) (encrypter, error) {
+	kind, arg, ok := strings.Cut(spec, ":")
+	if !ok || arg == "" {
+		return nil, errors.New("expected age:<recipients file> or gpg:<recipient>")
+	}
+	switch kind {
+	case "age":
+		return ageEncrypter(arg)
+	case "gpg":
+		return gpgEncrypter(arg), nil
+	default:
+		return nil, fmt.Errorf("unknown encryption method %q", kind)
+	}
+}
+
+// ageEncrypter returns an encrypter for all recipients listed in the given file.
+func ageEncrypter(recipientsFile string) (encrypter, error) {
+	f, err := os.Open(recipientsFile)
+	if err != nil {
+		return nil, fmt.Errorf("open recipients: %w", err)
+	}
+	defer f.Close()
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse recipients: %w", err)
+	}
+	return func(dst io.WriteCloser) (io.WriteCloser, error) {
+		w, err := age.Encrypt(dst, recipients...)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt output: %w", err)
+		}
+		return &encryptedWriter{Writer: w, closers: []func() error{w.Close}, dst: dst}, nil
+	}, nil
+}
+
+// gpgEncrypter returns an encrypter piping the output through the gpg command.
+func gpgEncrypter(recipient string) encrypter {
+	return func(dst io.WriteCloser) (io.WriteCloser, error) {
+		cmd := exec.Command("gpg", "--batch", "--yes", "--encrypt", "--recipient", recipient, "--output", "-")
+		cmd.Stdout = dst
+		cmd.Stderr = os.Stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("encrypt output: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("start gpg: %w", err)
+		}
+		wait := func() error {
+			if err := cmd.Wait(); err != nil {
+				return fmt.Errorf("gpg: %w", err)
+			}
+			return nil
+		}
+		return &encryptedWriter{Writer: stdin, closers: []func() error{stdin.Close, wait}, dst: dst}, nil
+	}
+}
+
+// encryptedWriter writes to the encrypting stream and,
+// on Close, calls all closers in order, closes the output and returns the first error.
+type encryptedWriter struct {
+	io.Writer
+	closers []func() error
+	dst     io.WriteCloser
+}
+
+// Close finalizes the encrypted stream and closes the underlying output.
+func (w *encryptedWriter) Close() error {
+	var first error
+	for _, c := range w.closers {
+		if err := c(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		abort(w.dst)
+		return first
+	}
+	return w.dst.Close()
+}
+
+// Abort stops the encryption and discards the underlying output.
+func (w *encryptedWriter) Abort() {
+	for _, c := range w.closers {
+		c()
+	}
+	abort(w.dst)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeEncrypter(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	recipients := filepath.Join(dir, "recipients.txt")
+	writeFile(t, recipients, "# team\n"+id.Recipient().String()+"\n")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	out := filepath.Join(dir, "out_{{.Name}}.txt")
+	if err := runCLI("-i", csv, "-t", "Hello {{.Name}}", "-o", out, "--encrypt-out", "age:"+recipients); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ann", "Bob"} {
+		data, err := os.ReadFile(filepath.Join(dir, "out_"+name+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte(name)) {
+			t.Errorf("output for %s is not encrypted", name)
+		}
+		r, err := age.Decrypt(bytes.NewReader(data), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clear, _ := io.ReadAll(r)
+		if got := string(clear); got != "Hello "+name {
+			t.Errorf("decrypted output = %q, want %q", got, "Hello "+name)
+		}
+	}
+}
+
+func TestNewEncrypter(t *testing.T) {
+	for _, spec := range []string{"", "age", "age:", "zip:key", "age:" + filepath.Join(t.TempDir(), "missing")} {
+		if _, err := newEncrypter(spec); err == nil {
+			t.Errorf("newEncrypter(%q): no error", spec)
+		}
+	}
+	if _, err := newEncrypter("gpg:alice@example.com"); err != nil {
+		t.Errorf("newEncrypter(gpg): %v", err)
+	}
+}
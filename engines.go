@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/cbroglie/mustache"
+	"github.com/flosch/pongo2/v6"
+)
+
+// engineContext returns the data of a template as a map for the mustache and pongo2 engines:
+// a row as is, a group with its Key, Rows and First, and the rows (or groups) of the single
+// file mode under rows.
+func engineContext(data any) map[string]any {
+	switch d := data.(type) {
+	case map[string]any:
+		return d
+	case group:
+		return map[string]any{"Key": d.Key, "Rows": d.Rows, "First": d.First()}
+	default:
+		return map[string]any{"rows": data}
+	}
+}
+
+// mustacheTemplate is a content template of the mustache engine.
+type mustacheTemplate struct {
+	tmpl *mustache.Template
+}
+
+// Execute renders the template with the data.
+func (t mustacheTemplate) Execute(w io.Writer, data any) error {
+	return t.tmpl.FRender(w, engineContext(data))
+}
+
+// parseMustache parses the first text as a mustache template, the others being its partials.
+// The values are HTML escaped only with --html, and missing with --strict is an error.
+func (a *app) parseMustache(texts []templateText) (executor, error) {
+	partials := make(map[string]string, len(texts)-1)
+	for _, t := range texts[1:] {
+		partials[t.name] = t.text
+		partials[strings.TrimSuffix(t.name, path.Ext(t.name))] = t.text
+	}
+	mustache.AllowMissingVariables = !a.strict
+	tmpl, err := mustache.ParseStringPartialsRaw(texts[0].text, &mustache.StaticProvider{Partials: partials}, !a.html)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return mustacheTemplate{tmpl: tmpl}, nil
+}
+
+// pongoTemplate is a content template of the pongo2 (Jinja like) engine.
+type pongoTemplate struct {
+	tmpl  *pongo2.Template
+	funcs template.FuncMap
+}
+
+// Execute renders the template with the data; the template functions can be called
+// like {{ upper(Name) }} (the fields take precedence).
+func (t pongoTemplate) Execute(w io.Writer, data any) error {
+	ctx := make(pongo2.Context, len(t.funcs))
+	for name, fn := range t.funcs {
+		ctx[name] = fn
+	}
+	for k, v := range engineContext(data) {
+		ctx[k] = v
+	}
+	return t.tmpl.ExecuteWriter(ctx, w)
+}
+
+// textLoader is a pongo2 loader of the partial templates, for include and extends.
+type textLoader map[string]string
+
+// Abs returns the name as is: the partials are named after their file base name.
+func (l textLoader) Abs(_, name string) string {
+	return name
+}
+
+// Get returns the text of the partial template.
+func (l textLoader) Get(name string) (io.Reader, error) {
+	text, ok := l[name]
+	if !ok {
+		return nil, fmt.Errorf("no template named %q", name)
+	}
+	return strings.NewReader(text), nil
+}
+
+// parsePongo parses the first text as a pongo2 template, the others being available to
+// include and extends. The values are HTML escaped only with --html.
+func (a *app) parsePongo(texts []templateText, funcs template.FuncMap) (executor, error) {
+	loader := make(textLoader, len(texts)-1)
+	for _, t := range texts[1:] {
+		loader[t.name] = t.text
+	}
+	pongo2.SetAutoescape(a.html)
+	tmpl, err := pongo2.NewSet("csvplate", loader).FromString(texts[0].text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return pongoTemplate{tmpl: tmpl, funcs: funcs}, nil
+}
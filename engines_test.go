@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestEngines(t *testing.T) {
+	csv := "Name,City\nAnn,Paris\nBob,<Lyon>\n"
+	tests := []struct {
+		engine, tmpl string
+		args         []string
+		want         string
+	}{
+		{"mustache", "{{#rows}}{{Name}} {{City}};{{/rows}}", nil, "Ann Paris;Bob <Lyon>;"},
+		{"mustache", "{{#rows}}{{City}};{{/rows}}", []string{"--html"}, "Paris;&lt;Lyon&gt;;"},
+		{"pongo2", "{% for r in rows %}{{ r.Name|lower }}{% if not forloop.Last %},{% endif %}{% endfor %}", nil, "ann,bob"},
+		{"pongo2", "{% for r in rows %}{{ toUpper(r.Name) }}{% endfor %}", nil, "ANNBOB"},
+	}
+	for _, tt := range tests {
+		got := renderCSV(t, csv, tt.tmpl, append([]string{"--engine", tt.engine}, tt.args...)...)
+		if got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.engine, tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestEngineContext(t *testing.T) {
+	row := map[string]any{"Name": "Ann"}
+	if ctx := engineContext(row); ctx["Name"] != "Ann" {
+		t.Errorf("row context = %v", ctx)
+	}
+	g := group{Key: "Paris", Rows: []map[string]any{row}}
+	if ctx := engineContext(g); ctx["Key"] != "Paris" || ctx["First"].(map[string]any)["Name"] != "Ann" {
+		t.Errorf("group context = %v", ctx)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// generated records an output file written by the run: all of them for --keep-last,
+// and the changed ones (not left untouched by --if-changed) for the --exec hook.
+func (a *app) generated(fileName string, changed bool) {
+	if fileName == "-" {
+		return
+	}
+	a.written = append(a.written, fileName)
+	if changed {
+		a.outputs = append(a.outputs, fileName)
+	}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookCommand returns the --exec command for the file: every {} is replaced by
+// the quoted file name, which is appended if there is no {}.
+func hookCommand(command, fileName string) string {
+	if !strings.Contains(command, "{}") {
+		return command + " " + shellQuote(fileName)
+	}
+	return strings.ReplaceAll(command, "{}", shellQuote(fileName))
+}
+
+// runHooks runs the --exec command (with sh -c) for every generated file,
+// at most execJobs at a time. All commands are run, and the failures are
+// reported together. In dry-run mode the commands are only listed.
+func (a *app) runHooks() error {
+	if a.execCommand == "" || len(a.outputs) == 0 {
+		return nil
+	}
+	if a.dryRun {
+		a.info("commands that would be run:\n")
+		for _, name := range a.outputs {
+			a.info("%s\n", hookCommand(a.execCommand, name))
+		}
+		return nil
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures int
+	)
+	jobs := make(chan string)
+	for range max(1, a.execJobs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				command := hookCommand(a.execCommand, name)
+				out, err := exec.Command("sh", "-c", command).CombinedOutput()
+				mu.Lock()
+				if len(out) > 0 {
+					os.Stderr.Write(out)
+				}
+				if err != nil {
+					failures++
+					fmt.Fprintf(os.Stderr, "  %s: %v\n", command, err)
+				} else {
+					a.debug("%s\n", command)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range a.outputs {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+	if failures > 0 {
+		return fmt.Errorf("%d of %d --exec commands failed", failures, len(a.outputs))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestHookCommand(t *testing.T) {
+	tests := []struct {
+		command, fileName, want string
+	}{
+		{"gzip", "out/a.txt", "gzip 'out/a.txt'"},
+		{"cp {} {}.bak", "a b.txt", "cp 'a b.txt' 'a b.txt'.bak"},
+		{"cat {}", "it's.txt", `cat 'it'\''s.txt'`},
+		{"echo", "$(rm -rf x);`y`", "echo '$(rm -rf x);`y`'"},
+	}
+	for _, tt := range tests {
+		if got := hookCommand(tt.command, tt.fileName); got != tt.want {
+			t.Errorf("hookCommand(%q, %q) = %q, want %q", tt.command, tt.fileName, got, tt.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	for _, s := range []string{"plain", "a b", "it's", `"$HOME"`, "a\nb", "''", "$(echo x)", ""} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("sh with %q: %v", s, err)
+		}
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) gives %q in sh", s, out)
+		}
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "{{.Name}}.txt"), "--exec", "cp {} {}.bak", "--exec-jobs", "2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ann.txt.bak", "Bob.txt.bak"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "{{.Name}}.txt"), "--force", "--exec", "test {} = nothing")
+	if err == nil || err.Error() != "2 of 2 --exec commands failed" {
+		t.Errorf("err = %v", err)
+	}
+}
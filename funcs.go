@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// envFuncs are the sprout functions reading the environment,
+// only available with --allow-env.
+var envFuncs = []string{"env", "expandEnv"}
+
+// funcMap returns all the functions available in the templates:
+// the sprout functions (of the --funcs-groups) and the csvplate specific ones.
+func (a *app) funcMap() (template.FuncMap, error) {
+	groups := a.funcsGroups
+	if a.noFuncs {
+		groups = []string{}
+	}
+	funcs, err := sproutFuncMap(groups)
+	if err != nil {
+		return nil, err
+	}
+	funcs["pseudonymize"] = a.pseudonymize
+	funcs["bytes"] = rawBytes
+	funcs["sourceFile"] = a.sourceFile
+	funcs["paginate"] = paginate
+	funcs["window"] = window
+	funcs["zip"] = zipLists
+	funcs["countLines"] = countLines
+	funcs["estimateLines"] = estimateLines
+	funcs["pageBreak"] = pageBreak
+	funcs["pager"] = newPager
+	funcs["decAdd"] = decAdd
+	funcs["decSub"] = decSub
+	funcs["decMul"] = decMul
+	funcs["decDiv"] = decDiv
+	funcs["decRound"] = decRound
+	funcs["convertUnit"] = convertUnit
+	funcs["humanSize"] = humanSize
+	funcs["geoDistance"] = geoDistance
+	funcs["formatDMS"] = formatDMS
+	funcs["parseDMS"] = parseDMS
+	funcs["formatAddress"] = formatAddress
+	funcs["salutation"] = salutation
+	funcs["nameCase"] = nameCase
+	funcs["fullName"] = fullName
+	funcs["isHoliday"] = a.isHoliday
+	funcs["holidayName"] = a.holidayName
+	funcs["isBusinessDay"] = a.isBusinessDay
+	funcs["addBusinessDays"] = a.addBusinessDays
+	funcs["age"] = ageYears
+	funcs["humanDuration"] = humanDuration
+	funcs["classify"] = classify
+	funcs["headers"] = func() []string { return a.headers }
+	funcs["sumBy"] = aggregateBy("sumBy", "sum")
+	funcs["avgBy"] = aggregateBy("avgBy", "avg")
+	funcs["minBy"] = aggregateBy("minBy", "min")
+	funcs["maxBy"] = aggregateBy("maxBy", "max")
+	funcs["countBy"] = countBy
+	funcs["symbol"] = a.symbol
+	funcs["diffText"] = diffText
+	funcs["regexExtract"] = regexExtract
+	funcs["levenshtein"] = levenshtein
+	funcs["similarity"] = similarity
+	funcs["closestMatch"] = closestMatch
+	if a.funcsScript != "" {
+		extra, err := scriptFuncs(a.funcsScript)
+		if err != nil {
+			return nil, err
+		}
+		for name, fn := range extra {
+			funcs[name] = fn
+		}
+	}
+	if err := restrictFuncs(funcs, a.allowFuncs, a.denyFuncs); err != nil {
+		return nil, err
+	}
+	if !a.allowEnv {
+		for _, name := range envFuncs {
+			delete(funcs, name)
+		}
+	}
+	return funcs, nil
+}
+
+// restrictFuncs removes from funcs all functions not in allow (if not empty)
+// and all functions in deny. Unknown allowed function names are reported as errors,
+// the denied ones are ignored (they are already absent).
+func restrictFuncs(funcs template.FuncMap, allow, deny []string) error {
+	for _, name := range allow {
+		if _, ok := funcs[name]; !ok {
+			return fmt.Errorf("unknown template function %q", name)
+		}
+	}
+	if len(allow) > 0 {
+		allowed := make(map[string]bool, len(allow))
+		for _, name := range allow {
+			allowed[name] = true
+		}
+		for name := range funcs {
+			if !allowed[name] {
+				delete(funcs, name)
+			}
+		}
+	}
+	for _, name := range deny {
+		delete(funcs, name)
+	}
+	return nil
+}
+
+// sourceFile returns the name of the CSV file ("stdin" or "inline" if not a file).
+func (a *app) sourceFile() string {
+	switch {
+	case a.csvPath == "-":
+		return "stdin"
+	case strings.Contains(a.csvPath, a.leftDelim) && strings.Contains(a.csvPath, a.rightDelim):
+		return "inline"
+	default:
+		return a.csvPath
+	}
+}
+
+// pseudonymize returns a stable pseudonym for the value:
+// the first 16 hex digits of its HMAC-SHA256 keyed by --pseudo-key-env.
+// The same value and key always give the same pseudonym.
+func (a *app) pseudonymize(value string) (string, error) {
+	if a.pseudoKey == nil {
+		return "", errors.New("no key, use --pseudo-key-env")
+	}
+	mac := hmac.New(sha256.New, a.pseudoKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8]), nil
+}
+
+// rawBytes returns the raw content of a binary cell (see --binary-columns),
+// to be written as is in the output. A string is decoded from base64.
+func rawBytes(v any) (string, error) {
+	switch b := v.(type) {
+	case []byte:
+		return string(b), nil
+	case string:
+		raw, err := base64.StdEncoding.DecodeString(b)
+		if err != nil {
+			return "", fmt.Errorf("bytes: %w", err)
+		}
+		return string(raw), nil
+	default:
+		return "", fmt.Errorf("bytes: unexpected value of type %T", v)
+	}
+}
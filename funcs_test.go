@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"testing"
+	"text/template"
+)
+
+func TestPseudonymize(t *testing.T) {
+	a := &app{pseudoKey: []byte("secret")}
+	p1, err := a.pseudonymize("ann@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("ann@example.com"))
+	if want := hex.EncodeToString(mac.Sum(nil))[:16]; p1 != want {
+		t.Errorf("pseudonymize = %s, want %s", p1, want)
+	}
+	if p2, _ := a.pseudonymize("ann@example.com"); p2 != p1 {
+		t.Errorf("pseudonymize is not stable: %s then %s", p1, p2)
+	}
+	other := &app{pseudoKey: []byte("other")}
+	if p3, _ := other.pseudonymize("ann@example.com"); p3 == p1 {
+		t.Error("pseudonymize does not depend on the key")
+	}
+	if _, err := (&app{}).pseudonymize("ann@example.com"); err == nil {
+		t.Error("pseudonymize without key: no error")
+	}
+}
+
+func TestAllowEnv(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		funcs, err := (&app{allowEnv: allow}).funcMap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range envFuncs {
+			if _, ok := funcs[name]; ok != allow {
+				t.Errorf("allowEnv=%v: %s available = %v", allow, name, ok)
+			}
+		}
+	}
+}
+
+func TestRestrictFuncs(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow, deny []string
+		want        []string
+		wantErr     bool
+	}{
+		{"none", nil, nil, []string{"env", "lower", "upper"}, false},
+		{"allow", []string{"lower"}, nil, []string{"lower"}, false},
+		{"deny", nil, []string{"env"}, []string{"lower", "upper"}, false},
+		{"deny unknown", nil, []string{"env", "exec", "readFile"}, []string{"lower", "upper"}, false},
+		{"allow and deny", []string{"lower", "upper"}, []string{"upper"}, []string{"lower"}, false},
+		{"allow unknown", []string{"exec"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs := template.FuncMap{"env": nil, "lower": nil, "upper": nil}
+			err := restrictFuncs(funcs, tt.allow, tt.deny)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restrictFuncs() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for name := range funcs {
+				got = append(got, name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("functions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawBytes(t *testing.T) {
+	for _, v := range []any{[]byte("\x00\xffPNG"), "AP9QTkc="} {
+		if got, err := rawBytes(v); err != nil || got != "\x00\xffPNG" {
+			t.Errorf("rawBytes(%v) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range []any{"not base64!", 42} {
+		if _, err := rawBytes(v); err == nil {
+			t.Errorf("rawBytes(%v): no error", v)
+		}
+	}
+}
+
+func TestBinaryColumns(t *testing.T) {
+	got := renderCSV(t, "Name,Photo\nAnn,AP9QTkc=\n", "{{range .}}{{bytes .Photo}}{{end}}", "--binary-columns", "Photo")
+	if got != "\x00\xffPNG" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSproutFuncMapGroups(t *testing.T) {
+	funcs, err := sproutFuncMap([]string{"strings", "maths"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := funcs["toUpper"]; !ok {
+		t.Error("toUpper missing from the strings group")
+	}
+	if _, ok := funcs["env"]; ok {
+		t.Error("env present without the env group")
+	}
+	if err := restrictFuncs(funcs, nil, []string{"env"}); err != nil {
+		t.Errorf("deny env without the env group: %v", err)
+	}
+	if _, err := sproutFuncMap([]string{"nope"}); err == nil {
+		t.Error("unknown group accepted")
+	}
+}
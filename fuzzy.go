@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// levenshtein returns the edit distance (insertions, deletions, substitutions of
+// characters) between the two texts.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarity returns how close two texts are, from 0 (nothing in common) to 1 (equal),
+// ignoring the case and the surrounding whitespace.
+func similarity(a, b any) float64 {
+	sa := strings.ToLower(strings.TrimSpace(fmt.Sprint(a)))
+	sb := strings.ToLower(strings.TrimSpace(fmt.Sprint(b)))
+	longest := max(len([]rune(sa)), len([]rune(sb)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(sa, sb))/float64(longest)
+}
+
+// closestMatch returns the candidate most similar to s (see similarity), the first one in case of tie:
+// {{.Dept | closestMatch (list "Sales" "Marketing")}}. The candidates are a list or a comma separated string.
+func closestMatch(candidates any, s any) (string, error) {
+	var names []string
+	if text, ok := candidates.(string); ok {
+		names = strings.Split(text, ",")
+	} else {
+		v, err := sliceValue("closestMatch", candidates)
+		if err != nil {
+			return "", err
+		}
+		for i := range v.Len() {
+			names = append(names, fmt.Sprint(v.Index(i).Interface()))
+		}
+	}
+	best, bestScore := "", -1.0
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if score := similarity(name, s); score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best, nil
+}
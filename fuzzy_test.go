@@ -0,0 +1,14 @@
+package main
+
+import "fmt"
+
+func Example_levenshtein() {
+	fmt.Println(levenshtein("kitten", "sitting"), levenshtein("", "abc"), levenshtein("été", "ete"))
+	fmt.Println(similarity(" Sales", "sales"), similarity("", ""))
+	best, _ := closestMatch("Sales, Marketing, Support", "markting")
+	fmt.Println(best)
+	// Output:
+	// 3 3 2
+	// 1 1
+	// Marketing
+}
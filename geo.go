@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// earthRadius is the mean Earth radius in kilometers.
+const earthRadius = 6371.0088
+
+// geoDistance returns the great-circle (haversine) distance in kilometers
+// between the points lat1,lon1 and lat2,lon2 given in decimal degrees.
+func geoDistance(lat1, lon1, lat2, lon2 any) (float64, error) {
+	coords := make([]float64, 4)
+	for i, v := range []any{lat1, lon1, lat2, lon2} {
+		f, ok := toNumber(v, true)
+		if !ok {
+			return 0, fmt.Errorf("geoDistance: %v is not a number", v)
+		}
+		coords[i] = f * math.Pi / 180
+	}
+	dLat := coords[2] - coords[0]
+	dLon := coords[3] - coords[1]
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(coords[0])*math.Cos(coords[2])*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h)), nil
+}
+
+// formatDMS formats a latitude ("lat") or longitude ("lon") in decimal degrees
+// as degrees, minutes and seconds: formatDMS "lat" 48.8566 gives 48°51'23.8"N.
+func formatDMS(axis string, v any) (string, error) {
+	f, ok := toNumber(v, true)
+	if !ok {
+		return "", fmt.Errorf("formatDMS: %v is not a number", v)
+	}
+	var positive, negative string
+	var limit float64
+	switch axis {
+	case "lat":
+		positive, negative, limit = "N", "S", 90
+	case "lon":
+		positive, negative, limit = "E", "W", 180
+	default:
+		return "", fmt.Errorf("formatDMS: unknown axis %q (expected lat or lon)", axis)
+	}
+	if math.Abs(f) > limit {
+		return "", fmt.Errorf("formatDMS: %v is out of range for a %s", v, axis)
+	}
+	hemisphere := positive
+	if f < 0 {
+		hemisphere = negative
+	}
+	// work in tenths of seconds, so that rounding carries to the minutes and degrees
+	tenths := int(math.Round(math.Abs(f) * 36000))
+	deg, minutes, seconds := tenths/36000, tenths/600%60, float64(tenths%600)/10
+	return fmt.Sprintf("%d°%d'%.1f\"%s", deg, minutes, seconds, hemisphere), nil
+}
+
+// dmsRe matches coordinates like 48°51'23.8"N, 48 51 23.8 N or -48°51.4'.
+var dmsRe = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*°?\s*(?:(\d+(?:\.\d+)?)\s*['′]?\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|'')?\s*)?([NSEWnsew])?\s*$`)
+
+// parseDMS converts a coordinate in degrees, minutes and seconds to decimal degrees
+// (negative for the south and west hemispheres).
+func parseDMS(s string) (float64, error) {
+	m := dmsRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("parseDMS: %q is not a coordinate", s)
+	}
+	deg, _ := strconv.ParseFloat(m[1], 64)
+	var minutes, seconds float64
+	if m[2] != "" {
+		minutes, _ = strconv.ParseFloat(m[2], 64)
+	}
+	if m[3] != "" {
+		seconds, _ = strconv.ParseFloat(m[3], 64)
+	}
+	if minutes >= 60 || seconds >= 60 {
+		return 0, fmt.Errorf("parseDMS: %q has minutes or seconds out of range", s)
+	}
+	result := math.Abs(deg) + minutes/60 + seconds/3600
+	if strings.HasPrefix(m[1], "-") || strings.ContainsAny(m[4], "SWsw") {
+		result = -result
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeoDistance(t *testing.T) {
+	// Paris - London
+	d, err := geoDistance(48.8566, 2.3522, "51.5074", "-0.1278")
+	if err != nil || math.Abs(d-343.6) > 0.5 {
+		t.Errorf("geoDistance = %v, %v, want about 343.6", d, err)
+	}
+	if _, err := geoDistance(0, 0, 0, "east"); err == nil {
+		t.Error("not a number: no error")
+	}
+}
+
+func TestDMS(t *testing.T) {
+	tests := []struct {
+		axis string
+		v    float64
+		dms  string
+	}{
+		{"lat", 48.8566, `48°51'23.8"N`},
+		{"lon", -0.1278, `0°7'40.1"W`},
+		{"lat", -33.999999, `34°0'0.0"S`},
+	}
+	for _, tt := range tests {
+		got, err := formatDMS(tt.axis, tt.v)
+		if err != nil || got != tt.dms {
+			t.Errorf("formatDMS(%q, %v) = %q, %v, want %q", tt.axis, tt.v, got, err, tt.dms)
+		}
+		back, err := parseDMS(got)
+		if err != nil || math.Abs(back-tt.v) > 1e-4 {
+			t.Errorf("parseDMS(%q) = %v, %v, want %v", got, back, err, tt.v)
+		}
+	}
+	if _, err := formatDMS("lat", 91); err == nil {
+		t.Error("latitude 91: no error")
+	}
+	for _, s := range []string{"48 51 23.8 N", "-48°51.4'"} {
+		if _, err := parseDMS(s); err != nil {
+			t.Errorf("parseDMS(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"north", `48°61'0"N`} {
+		if _, err := parseDMS(s); err == nil {
+			t.Errorf("parseDMS(%q): no error", s)
+		}
+	}
+}
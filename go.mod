@@ -3,9 +3,18 @@ module github.com/kpym/csvplate
 go 1.25.4
 
 require (
+	filippo.io/age v1.2.1
+	github.com/BurntSushi/toml v1.6.0
+	github.com/cbroglie/mustache v1.4.0
+	github.com/flosch/pongo2/v6 v6.0.0
 	github.com/go-sprout/sprout v1.0.2
 	github.com/kpym/utf8reader v0.5.1
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/shopspring/decimal v1.4.0
 	github.com/spf13/pflag v1.0.10
+	go.starlark.net v0.0.0-20231121155337-90ade8b19d09
+	golang.org/x/text v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,7 +26,7 @@ require (
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	github.com/spf13/cast v1.9.2 // indirect
-	golang.org/x/net v0.31.0 // indirect
-	golang.org/x/text v0.31.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.42.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
 )
@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// group is a set of rows sharing the same value in the --group-by column.
+type group struct {
+	// Key is the common value of the grouping column.
+	Key string
+	// Rows are the rows of the group, in their original order.
+	Rows []map[string]any
+}
+
+// First returns the first row of the group,
+// handy to access fields common to the whole group.
+func (g group) First() map[string]any {
+	return g.Rows[0]
+}
+
+// groupRows groups the rows by the value of the given column.
+// The groups are ordered by first appearance of their key.
+func groupRows(rows []map[string]any, column string) []group {
+	var groups []group
+	index := make(map[string]int)
+	for _, row := range rows {
+		v, _ := getField(row, column)
+		key := fmt.Sprint(v)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group{Key: key})
+		}
+		groups[i].Rows = append(groups[i].Rows, row)
+	}
+	return groups
+}
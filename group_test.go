@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	csv := "Customer,Item\nACME,bolt\nInitech,stapler\nACME,nut\n"
+
+	single := renderCSV(t, csv, "{{range .}}{{.Key}}({{.First.Item}}):{{range .Rows}} {{.Item}}{{end}};{{end}}", "-g", "Customer")
+	if want := "ACME(bolt): bolt nut;Initech(stapler): stapler;"; single != want {
+		t.Errorf("single file: got %q, want %q", single, want)
+	}
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeFile(t, in, csv)
+	if err := runCLI("-i", in, "-t", "{{len .Rows}}", "-o", filepath.Join(dir, "invoice_{{.Key}}.txt"), "--group-by", "Customer"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"invoice_ACME.txt": "2", "invoice_Initech.txt": "1"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("%s = %q (%v), want %q", name, data, err, want)
+		}
+	}
+
+	if err := runCLI("-i", in, "-t", "x", "-o", filepath.Join(dir, "out.txt"), "--group-by", "Country"); err == nil {
+		t.Error("group by an unknown column: no error")
+	}
+}
+
+func TestLocalCounter(t *testing.T) {
+	csv := "Customer,Item\nACME,bolt\nInitech,stapler\nACME,nut\n"
+	got := renderCSV(t, csv, "{{range .}}{{range .Rows}}{{._index_}}.{{._local_}} {{end}}{{end}}", "-g", "Customer")
+	if want := "1.1 3.2 2.1 "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got = renderCSV(t, csv, "{{range .}}{{range .Rows}}{{.n}}{{end}}{{end}}", "-g", "Customer", "--local-counter", "n")
+	if want := "121"; got != want {
+		t.Errorf("--local-counter n: got %q, want %q", got, want)
+	}
+}
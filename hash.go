@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+)
+
+// hashOutputs renders the outputs in memory and names them after the SHA-256 of their
+// content (--name-by-hash): the rendered name is the directory of <hash><hash-ext>.
+// The content is the final one (encoded and post-processed), written as is by rawWriter.
+// The outputs with the same content are merged, the content being written once.
+// The rendering errors are handled by renderFailed.
+func (a *app) hashOutputs(contentTmpl executor, outputs []output, renderErrors *int) ([]output, error) {
+	result := make([]output, 0, len(outputs))
+	index := make(map[string]int, len(outputs))
+	for _, o := range outputs {
+		content, err := a.renderFinal(contentTmpl, o.units, filepath.Join(o.name, "content"+a.hashExt))
+		if err != nil {
+			if err := a.renderFailed(fmt.Errorf("render template for %s: %w", o.units[0].name, err), renderErrors); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sum := sha256.Sum256(content)
+		name := filepath.Join(o.name, hex.EncodeToString(sum[:])+a.hashExt)
+		if i, seen := index[name]; seen {
+			result[i].units = append(result[i].units, o.units...)
+			continue
+		}
+		index[name] = len(result)
+		result = append(result, output{name: name, units: o.units, content: content})
+	}
+	return result, nil
+}
+
+// renderFinal renders the units in memory through the output encoding and post-processing
+// (the banner style depending on fileName), giving the bytes written to the file.
+func (a *app) renderFinal(contentTmpl executor, units []unit, fileName string) ([]byte, error) {
+	buf := &bufferCloser{}
+	enc, err := a.encode(buf, true)
+	if err != nil {
+		return nil, err
+	}
+	w := a.postprocess(enc, fileName)
+	for _, u := range units {
+		if err := contentTmpl.Execute(w, u.data); err != nil {
+			abort(w)
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bufferCloser is an in-memory output.
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+// Close does nothing.
+func (*bufferCloser) Close() error { return nil }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestNameByHash(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\nAnn\n")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "store"), "--name-by-hash", "--hash-ext", ".txt"); err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]string)
+	for _, content := range []string{"Ann", "Bob"} {
+		sum := sha256.Sum256([]byte(content))
+		want[hex.EncodeToString(sum[:])+".txt"] = content
+	}
+	if got := readTree(t, filepath.Join(dir, "store")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestHashOutputs(t *testing.T) {
+	tmpl := template.Must(template.New("content").Parse("{{.Name}}\nend\n"))
+	units := []unit{
+		{name: "row 0", data: map[string]any{"Name": "Ann"}},
+		{name: "row 1", data: map[string]any{"Name": "Bob"}},
+		{name: "row 2", data: map[string]any{"Name": "Ann"}},
+	}
+	outputs := make([]output, len(units))
+	for i, u := range units {
+		outputs[i] = output{name: "store", units: []unit{u}}
+	}
+	tests := []struct {
+		name string
+		app  *app
+		want string
+	}{
+		{"plain", &app{hashExt: ".txt"}, "Ann\nend\n"},
+		{"crlf", &app{hashExt: ".txt", crlf: true}, "Ann\r\nend\r\n"},
+		{"bom", &app{hashExt: ".txt", bom: true}, "\ufeffAnn\nend\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var renderErrors int
+			got, err := tt.app.hashOutputs(tmpl, outputs, &renderErrors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 {
+				t.Fatalf("got %d outputs, want 2 (identical contents merged)", len(got))
+			}
+			if len(got[0].units) != 2 {
+				t.Errorf("got %d units in the first output, want 2", len(got[0].units))
+			}
+			if string(got[0].content) != tt.want {
+				t.Errorf("content = %q, want %q", got[0].content, tt.want)
+			}
+			for _, o := range got {
+				sum := sha256.Sum256(o.content)
+				if want := filepath.Join("store", hex.EncodeToString(sum[:])+".txt"); o.name != want {
+					t.Errorf("name = %s, want %s", o.name, want)
+				}
+			}
+		})
+	}
+}
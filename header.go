@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// looksLikeHeader tells whether the first record looks like a header line:
+// all its cells are non-empty, unique and neither numbers nor dates.
+// When it does not, the reason is returned.
+func looksLikeHeader(record []string) (bool, string) {
+	seen := make(map[string]bool, len(record))
+	for i, cell := range record {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case cell == "":
+			return false, "column " + strconv.Itoa(i+1) + " is empty"
+		case seen[cell]:
+			return false, strconv.Quote(cell) + " is repeated"
+		case isNumeric(cell):
+			return false, strconv.Quote(cell) + " is a number"
+		case isDate(cell):
+			return false, strconv.Quote(cell) + " is a date"
+		}
+		seen[cell] = true
+	}
+	return true, "all cells are unique non-numeric names"
+}
+
+// isNumeric tells whether s is a number (possibly with leading zeros or a decimal comma).
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return err == nil
+}
+
+// isDate tells whether s is an ISO 8601 date.
+func isDate(s string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeHeaders applies the --duplicate-headers policy to the repeated header names:
+//   - "error" fails,
+//   - "rename" renames the later ones Name_2, Name_3, ...
+//
+// Without a policy the headers are kept (the later column wins) and a warning is printed.
+func (a *app) dedupeHeaders(headers []string) ([]string, error) {
+	seen := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		seen[h] = true
+	}
+	result := make([]string, len(headers))
+	used := make(map[string]bool, len(headers))
+	warned := make(map[string]bool)
+	for i, h := range headers {
+		result[i] = h
+		if !used[h] {
+			used[h] = true
+			continue
+		}
+		switch a.dupHeaders {
+		case "error":
+			return nil, fmt.Errorf("duplicate column %q (see --duplicate-headers)", h)
+		case "rename":
+			name := h
+			for n := 2; seen[name]; n++ {
+				name = fmt.Sprintf("%s_%d", h, n)
+			}
+			seen[name] = true
+			result[i] = name
+		default:
+			if !warned[h] {
+				warned[h] = true
+				a.warn("duplicate column %q, the last one is used (see --duplicate-headers)\n", h)
+			}
+		}
+	}
+	return result, nil
+}
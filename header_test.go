@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLooksLikeHeader(t *testing.T) {
+	tests := []struct {
+		record []string
+		header bool
+	}{
+		{[]string{"Name", "Age", "Born"}, true},
+		{[]string{"Name", "", "Born"}, false},
+		{[]string{"Name", "Name"}, false},
+		{[]string{"Ann", "0042"}, false},
+		{[]string{"Ann", "3,5"}, false},
+		{[]string{"Ann", "2024-05-01"}, false},
+	}
+	for _, tt := range tests {
+		if got, reason := looksLikeHeader(tt.record); got != tt.header {
+			t.Errorf("looksLikeHeader(%q) = %v (%s), want %v", tt.record, got, reason, tt.header)
+		}
+	}
+}
+
+func TestAutoHeader(t *testing.T) {
+	tmpl := "{{range .}}{{.C1}}/{{.C2}} {{end}}"
+	if got := renderCSV(t, "Ann,30\nBob,41\n", tmpl, "--header", "auto"); got != "Ann/30 Bob/41 " {
+		t.Errorf("data first line: got %q", got)
+	}
+	if got := renderCSV(t, "Name,Age\nAnn,30\n", "{{range .}}{{.Name}}{{end}}", "--header", "auto"); got != "Ann" {
+		t.Errorf("header first line: got %q", got)
+	}
+}
+
+func TestDedupeHeaders(t *testing.T) {
+	headers := []string{"Name", "Phone", "Phone", "Phone_2", "Phone"}
+	got, err := (&app{dupHeaders: "rename"}).dedupeHeaders(headers)
+	if want := []string{"Name", "Phone", "Phone_3", "Phone_2", "Phone_4"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("rename = %q, %v, want %q", got, err, want)
+	}
+	if _, err := (&app{dupHeaders: "error"}).dedupeHeaders(headers); err == nil {
+		t.Error("error policy: no error")
+	}
+	for _, level := range []int{quiet, normal} {
+		var got []string
+		var err error
+		warning := captureStderr(t, func() { got, err = (&app{verbosity: level}).dedupeHeaders(headers) })
+		if err != nil || !reflect.DeepEqual(got, headers) {
+			t.Errorf("no policy = %q, %v", got, err)
+		}
+		if (warning != "") != (level == normal) {
+			t.Errorf("verbosity %d: warning %q", level, warning)
+		}
+	}
+	if got := renderCSV(t, "A,A\n1,2\n", "{{range .}}{{.A}}-{{.A_2}}{{end}}", "--duplicate-headers", "rename"); got != "1-2" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// holidays is the --holidays calendar: dated holidays (2026-04-06)
+// and holidays repeated every year (12-25), with their names.
+// Example file:
+//
+//	# France
+//	01-01 New Year's Day
+//	2026-04-06 Easter Monday
+//	12-25 Christmas
+type holidays struct {
+	dates  map[string]string
+	yearly map[string]string
+}
+
+// loadHolidays reads the holidays file: one holiday per line, a date (YYYY-MM-DD
+// or MM-DD for every year) optionally followed by a name; empty lines and # comments are ignored.
+func loadHolidays(path string) (*holidays, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read holidays: %w", err)
+	}
+	defer f.Close()
+	h := &holidays{dates: make(map[string]string), yearly: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		date, name, _ := strings.Cut(line, " ")
+		name = strings.TrimSpace(name)
+		if _, err := time.Parse("2006-01-02", date); err == nil {
+			h.dates[date] = name
+		} else if _, err := time.Parse("01-02", date); err == nil {
+			h.yearly[date] = name
+		} else {
+			return nil, fmt.Errorf("read holidays: line %d: invalid date %q", n, date)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read holidays: %w", err)
+	}
+	return h, nil
+}
+
+// lookup returns the name of the holiday at the date and whether it is a holiday.
+func (h *holidays) lookup(t time.Time) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	if name, ok := h.dates[t.Format("2006-01-02")]; ok {
+		return name, true
+	}
+	name, ok := h.yearly[t.Format("01-02")]
+	return name, ok
+}
+
+// toDate converts a time.Time or a string in one of the dateLayouts to a time.Time.
+func toDate(function string, v any) (time.Time, error) {
+	switch d := v.(type) {
+	case time.Time:
+		return d, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(d)); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: %v is not a date", function, v)
+}
+
+// isHoliday tells whether the date is a --holidays holiday.
+func (a *app) isHoliday(date any) (bool, error) {
+	t, err := toDate("isHoliday", date)
+	if err != nil {
+		return false, err
+	}
+	_, ok := a.holidays.lookup(t)
+	return ok, nil
+}
+
+// holidayName returns the name of the --holidays holiday at the date, or an empty string.
+func (a *app) holidayName(date any) (string, error) {
+	t, err := toDate("holidayName", date)
+	if err != nil {
+		return "", err
+	}
+	name, _ := a.holidays.lookup(t)
+	return name, nil
+}
+
+// businessDay tells whether the date is neither a week-end day nor a holiday.
+func (a *app) businessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	_, ok := a.holidays.lookup(t)
+	return !ok
+}
+
+// isBusinessDay tells whether the date is neither a week-end day nor a --holidays holiday.
+func (a *app) isBusinessDay(date any) (bool, error) {
+	t, err := toDate("isBusinessDay", date)
+	if err != nil {
+		return false, err
+	}
+	return a.businessDay(t), nil
+}
+
+// addBusinessDays returns the date n business days after the date (before it if n is negative),
+// skipping the week-ends and the --holidays holidays.
+func (a *app) addBusinessDays(n int, date any) (time.Time, error) {
+	t, err := toDate("addBusinessDays", date)
+	if err != nil {
+		return t, err
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if a.businessDay(t) {
+			n--
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHolidays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.txt")
+	writeFile(t, path, "# France\n01-01 New Year's Day\n2026-04-06 Easter Monday\n\n12-25\n")
+	h, err := loadHolidays(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &app{holidays: h}
+	if name, _ := a.holidayName("2027-01-01"); name != "New Year's Day" {
+		t.Errorf("holidayName(2027-01-01) = %q", name)
+	}
+	if ok, _ := a.isHoliday("2027-04-06"); ok {
+		t.Error("2027-04-06 is a holiday")
+	}
+	if ok, _ := a.isHoliday(time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)); !ok {
+		t.Error("2030-12-25 is not a holiday")
+	}
+	// Friday 2026-04-03, then the week-end and Easter Monday
+	if d, err := a.addBusinessDays(1, "2026-04-03"); err != nil || d.Format("2006-01-02") != "2026-04-07" {
+		t.Errorf("addBusinessDays(1) = %v, %v", d, err)
+	}
+	if d, _ := a.addBusinessDays(-1, "2026-04-07"); d.Format("2006-01-02") != "2026-04-03" {
+		t.Errorf("addBusinessDays(-1) = %v", d)
+	}
+	if ok, _ := a.isBusinessDay("2026-04-04"); ok {
+		t.Error("a Saturday is a business day")
+	}
+	if _, err := a.isHoliday("tomorrow"); err == nil {
+		t.Error("invalid date: no error")
+	}
+
+	writeFile(t, path, "2026-13-01 Nope\n")
+	if _, err := loadHolidays(path); err == nil {
+		t.Error("invalid date in the file: no error")
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "strings"
+
+// invisibleChars are the byte order marks and zero-width characters removed
+// from the header names (they make them silently differ) and, with
+// --strip-invisible, from the data cells.
+const invisibleChars = "\ufeff\u200b\u200c\u200d\u2060"
+
+// stripInvisible removes the invisible characters from all cells of the record
+// and returns the number of modified cells.
+func stripInvisible(record []string) int {
+	var n int
+	for i, cell := range record {
+		if !strings.ContainsAny(cell, invisibleChars) {
+			continue
+		}
+		record[i] = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(invisibleChars, r) {
+				return -1
+			}
+			return r
+		}, cell)
+		n++
+	}
+	return n
+}
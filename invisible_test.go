@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripInvisible(t *testing.T) {
+	record := []string{"\ufeffName", "A\u200bge", "plain", "\u2060"}
+	if n := stripInvisible(record); n != 3 {
+		t.Errorf("cleaned cells = %d, want 3", n)
+	}
+	if want := []string{"Name", "Age", "plain", ""}; !reflect.DeepEqual(record, want) {
+		t.Errorf("record = %q, want %q", record, want)
+	}
+}
+
+func TestInvisibleCells(t *testing.T) {
+	// the joiners of the data cells are kept, e.g. in emoji sequences and Persian words
+	csv := "\ufeffName,Word\nAnn,\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645\nBob,\u200bx\n"
+	tmpl := "{{range .}}{{.Name}}={{.Word}};{{end}}"
+	if got, want := renderCSV(t, csv, tmpl), "Ann=\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645;Bob=\u200bx;"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, tmpl, "--strip-invisible"), "Ann=\u0645\u06cc\u062e\u0648\u0627\u0647\u0645;Bob=x;"; got != want {
+		t.Errorf("--strip-invisible: got %q, want %q", got, want)
+	}
+	report := captureStderr(t, func() { renderCSV(t, csv, tmpl, "--quiet") })
+	if report != "" {
+		t.Errorf("--quiet: report %q", report)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// labelSheet is the N-up layout of the --labels preset.
+type labelSheet struct {
+	Cols, Rows int
+	// Margin is the page margin, as a CSS or LaTeX length (e.g. 10mm)
+	Margin string
+	// format is html or latex
+	format string
+}
+
+// parseLabels parses the --labels value COLSxROWS (e.g. 3x8).
+func parseLabels(spec, margin, format string) (*labelSheet, error) {
+	c, r, ok := strings.Cut(strings.ToLower(spec), "x")
+	cols, err1 := strconv.Atoi(c)
+	rows, err2 := strconv.Atoi(r)
+	if !ok || err1 != nil || err2 != nil || cols <= 0 || rows <= 0 {
+		return nil, fmt.Errorf("expected COLSxROWS (e.g. 3x8), got %q", spec)
+	}
+	switch format {
+	case "", "html", "latex":
+	default:
+		return nil, fmt.Errorf("unknown label format %q (expected html or latex)", format)
+	}
+	return &labelSheet{Cols: cols, Rows: rows, Margin: margin, format: format}, nil
+}
+
+// labelPage is a page of the label sheet: a grid of rendered labels.
+type labelPage struct {
+	Lines [][]string
+	Last  bool
+}
+
+// htmlSheet tiles the labels in a CSS grid, one grid per printed page.
+const htmlSheet = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<style>
+@page { size: A4; margin: {{.Margin}}; }
+body { margin: 0; }
+.sheet { display: grid; grid-template-columns: repeat({{.Cols}}, 1fr); grid-template-rows: repeat({{.Rows}}, 1fr); height: calc(297mm - 2 * {{.Margin}}); page-break-after: always; }
+.sheet:last-child { page-break-after: auto; }
+.label { overflow: hidden; padding: 2mm; box-sizing: border-box; white-space: pre-line; }
+</style>
+</head>
+<body>
+{{- range .Pages}}
+<div class="sheet">
+{{- range .Lines}}{{range .}}
+<div class="label">{{.}}</div>
+{{- end}}{{end}}
+</div>
+{{- end}}
+</body>
+</html>
+`
+
+// latexSheet tiles the labels in fixed size minipages, one page per sheet.
+const latexSheet = `\documentclass{article}
+\usepackage[a4paper,margin={{.Margin}}]{geometry}
+\pagestyle{empty}
+\setlength{\parindent}{0pt}
+\begin{document}
+{{- range .Pages}}
+{{- range .Lines}}
+{{range .}}\begin{minipage}[t][\dimexpr\textheight/{{$.Rows}}\relax][t]{\dimexpr\textwidth/{{$.Cols}}\relax}
+{{.}}
+\end{minipage}%
+{{end}}\par
+{{- end}}
+{{if not .Last}}\newpage{{end}}
+{{- end}}
+\end{document}
+`
+
+// writeLabels renders the content template for every row (a label) and writes
+// the labels tiled in sheets of Cols x Rows labels in the single output file.
+func (a *app) writeLabels(tmpl executor, rows []map[string]any) error {
+	if a.perRow() {
+		return errors.New("--labels needs a single output file")
+	}
+	format := a.labels.format
+	if format == "" {
+		format = "html"
+		if ext := strings.ToLower(filepath.Ext(a.outPath)); ext == ".tex" {
+			format = "latex"
+		}
+	}
+	sheetText := htmlSheet
+	if format == "latex" {
+		sheetText = latexSheet
+	}
+	sheet := template.Must(template.New("sheet").Parse(sheetText))
+
+	// Render the labels and tile them
+	perPage := a.labels.Cols * a.labels.Rows
+	var pages []labelPage
+	var label strings.Builder
+	for i, row := range rows {
+		if i%perPage == 0 {
+			pages = append(pages, labelPage{})
+		}
+		p := &pages[len(pages)-1]
+		if i%a.labels.Cols == 0 {
+			p.Lines = append(p.Lines, nil)
+		}
+		label.Reset()
+		if err := tmpl.Execute(&label, row); err != nil {
+			return fmt.Errorf("render label for row %d: %w", i, err)
+		}
+		p.Lines[len(p.Lines)-1] = append(p.Lines[len(p.Lines)-1], strings.TrimSpace(label.String()))
+	}
+	if len(pages) > 0 {
+		pages[len(pages)-1].Last = true
+	}
+
+	f, err := a.writer(a.outPath)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		*labelSheet
+		Pages []labelPage
+	}{a.labels, pages}
+	if err := sheet.Execute(f, data); err != nil {
+		abort(f)
+		a.recordFailure(a.outPath, rows, err)
+		return fmt.Errorf("render label sheet: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		a.recordFailure(a.outPath, rows, err)
+		return fmt.Errorf("close output: %w", err)
+	}
+	a.recordOutput(a.outPath, rows, f)
+	if err := a.recordAudit(a.outPath, outputSink(a.outPath), rows); err != nil {
+		return err
+	}
+	if err := a.recordLatest(a.outPath, nil); err != nil {
+		return err
+	}
+	a.generated(a.outPath, true)
+	if a.outPath != "-" {
+		a.info("%d labels on %d sheets saved in %s\n", len(rows), len(pages), a.outPath)
+	}
+	return nil
+}
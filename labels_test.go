@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	s, err := parseLabels("3X8", "5mm", "latex")
+	if err != nil || s.Cols != 3 || s.Rows != 8 || s.Margin != "5mm" || s.format != "latex" {
+		t.Errorf("parseLabels = %+v, %v", s, err)
+	}
+	for _, spec := range []string{"3", "3x", "0x8", "axb"} {
+		if _, err := parseLabels(spec, "", ""); err == nil {
+			t.Errorf("parseLabels(%q): no error", spec)
+		}
+	}
+	if _, err := parseLabels("3x8", "", "pdf"); err == nil {
+		t.Error("pdf format: no error")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\nEve\n")
+	out := filepath.Join(dir, "labels.tex")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", out, "--labels", "2x1"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	tex := string(data)
+	if strings.Count(tex, `\begin{minipage}`) != 3 || strings.Count(tex, `\newpage`) != 1 || !strings.Contains(tex, "\nEve\n") {
+		t.Errorf("labels.tex =\n%s", tex)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// recordLatest renders the --latest-link name for an output written with the data
+// and remembers the output as the link target: the last output of every link wins.
+// A link name without directory is in the directory of the output.
+func (a *app) recordLatest(outName string, data any) error {
+	if a.latestTmpl == nil || outName == "-" {
+		return nil
+	}
+	var b strings.Builder
+	if err := a.latestTmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("render --latest-link for %s: %w", outName, err)
+	}
+	link := b.String()
+	if filepath.Base(link) == link {
+		link = filepath.Join(filepath.Dir(outName), link)
+	}
+	if a.latest == nil {
+		a.latest = make(map[string]string)
+	}
+	a.latest[link] = outName
+	return nil
+}
+
+// usesFields reports whether the template uses fields of the dot (or of $).
+func usesFields(t *template.Template) bool {
+	var found bool
+	walkFields(t.Tree.Root, func(parse.Node, string) { found = true })
+	return found
+}
+
+// linkLatest creates (or replaces) the --latest-link symbolic links, pointing at their
+// output with a path relative to the link. An existing file that is not a link is an error.
+func (a *app) linkLatest() error {
+	if a.dryRun {
+		return nil
+	}
+	for _, link := range slices.Sorted(maps.Keys(a.latest)) {
+		if filepath.Clean(link) == filepath.Clean(a.latest[link]) {
+			return fmt.Errorf("--latest-link %s is an output", link)
+		}
+		if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("--latest-link %s exists and is not a symbolic link", link)
+		}
+		target, err := filepath.Rel(filepath.Dir(link), a.latest[link])
+		if err != nil {
+			return fmt.Errorf("link %s: %w", link, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+			return fmt.Errorf("link %s: %w", link, err)
+		}
+		// Replace the link atomically
+		tmp := link + ".csvplate-link"
+		os.Remove(tmp)
+		if err := os.Symlink(target, tmp); err != nil {
+			return fmt.Errorf("link %s: %w", link, err)
+		}
+		if err := os.Rename(tmp, link); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("link %s: %w", link, err)
+		}
+		a.debug("%s -> %s\n", link, target)
+	}
+	return nil
+}
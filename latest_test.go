@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+func TestLatestLink(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	for i := range 2 {
+		if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "r_{{.Name}}.txt"), "--latest-link", "latest.txt", "-f"); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+	target, err := os.Readlink(filepath.Join(dir, "latest.txt"))
+	if err != nil || target != "r_Bob.txt" {
+		t.Errorf("latest.txt -> %q (%v), want r_Bob.txt", target, err)
+	}
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "r_{{.Name}}.txt"), "--latest-link", "r_Ann.txt", "-f"); err == nil {
+		t.Error("a link replacing an output: no error")
+	}
+}
+
+func TestUsesFields(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"latest.pdf", false},
+		{`latest-{{now | date "2006"}}.pdf`, false},
+		{"reports/{{.Key}}/latest.pdf", true},
+		{"{{with $x := 1}}{{$.Key}}{{end}}", true},
+	}
+	funcs := template.FuncMap{"now": func() string { return "" }, "date": func(string, string) string { return "" }}
+	for _, tt := range tests {
+		tmpl := template.Must(template.New("latest-link").Funcs(funcs).Parse(tt.text))
+		if got := usesFields(tmpl); got != tt.want {
+			t.Errorf("usesFields(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLinkLatest(t *testing.T) {
+	dir := t.TempDir()
+	a := &app{latestTmpl: template.Must(template.New("latest-link").Parse("latest-{{.C}}.txt"))}
+	for _, row := range []struct{ c, n string }{{"x", "1"}, {"y", "2"}, {"x", "3"}} {
+		name := filepath.Join(dir, "r"+row.n+".txt")
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.recordLatest(name, map[string]any{"C": row.c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.linkLatest(); err != nil {
+		t.Fatal(err)
+	}
+	for link, want := range map[string]string{"latest-x.txt": "r3.txt", "latest-y.txt": "r2.txt"} {
+		got, err := os.Readlink(filepath.Join(dir, link))
+		if err != nil || got != want {
+			t.Errorf("%s -> %q (%v), want %q", link, got, err, want)
+		}
+	}
+}
+
+func TestLatestLinkNewDir(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	link := filepath.Join(dir, "links", "latest.txt")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "r_{{.Name}}.txt"), "--latest-link", link, "--dry-run"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(link)); !os.IsNotExist(err) {
+		t.Errorf("the link directory is created in dry-run mode (%v)", err)
+	}
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "r_{{.Name}}.txt"), "--latest-link", link); err != nil {
+		t.Fatal(err)
+	}
+	target, err := os.Readlink(link)
+	if err != nil || target != filepath.Join("..", "r_Ann.txt") {
+		t.Errorf("%s -> %q (%v), want ../r_Ann.txt", link, target, err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseSize parses a size in bytes with an optional K, M or G suffix (powers of 1024),
+// possibly followed by B (10M, 10MB, 512kb and 100B are valid).
+func parseSize(s string) (int, error) {
+	n, unit := strings.ToUpper(s), 1
+	n = strings.TrimSuffix(n, "B")
+	switch {
+	case strings.HasSuffix(n, "K"):
+		n, unit = n[:len(n)-1], 1<<10
+	case strings.HasSuffix(n, "M"):
+		n, unit = n[:len(n)-1], 1<<20
+	case strings.HasSuffix(n, "G"):
+		n, unit = n[:len(n)-1], 1<<30
+	}
+	v, err := strconv.Atoi(n)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected a number of bytes with an optional K, M or G suffix, and B)", s)
+	}
+	if v > math.MaxInt/unit {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return v * unit, nil
+}
+
+// readString reads r until EOF directly into a string, without the extra
+// copy of io.ReadAll followed by a conversion, which matters for huge inputs.
+// The expected size (if known) is used to allocate the buffer once.
+func readString(r io.Reader, size int64) (string, error) {
+	var b strings.Builder
+	if size > 0 {
+		b.Grow(int(size) + 1)
+	}
+	_, err := io.Copy(&b, r)
+	return b.String(), err
+}
+
+// checkFieldSize returns an error for the first cell of the record larger than limit bytes.
+func checkFieldSize(record []string, limit int) error {
+	for i, cell := range record {
+		if len(cell) > limit {
+			return fmt.Errorf("field %d has %d bytes, more than --max-field-size %d", i+1, len(cell), limit)
+		}
+	}
+	return nil
+}
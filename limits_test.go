@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"100", 100, false},
+		{"100B", 100, false},
+		{"100b", 100, false},
+		{"2K", 2 << 10, false},
+		{"512KB", 512 << 10, false},
+		{"512kb", 512 << 10, false},
+		{"10M", 10 << 20, false},
+		{"10MB", 10 << 20, false},
+		{"1G", 1 << 30, false},
+		{"1gB", 1 << 30, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"10BB", 0, true},
+		{"10T", 0, true},
+		{"-1K", 0, true},
+		{"9223372036854775807K", 0, true},
+		{"8589934592G", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSize(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadString(t *testing.T) {
+	for _, size := range []int64{0, 3, 100} {
+		if got, err := readString(strings.NewReader("a,b\n"), size); err != nil || got != "a,b\n" {
+			t.Errorf("readString(size %d) = %q, %v", size, got, err)
+		}
+	}
+}
+
+func TestMaxFieldSize(t *testing.T) {
+	if err := checkFieldSize([]string{"abc", "de"}, 3); err != nil {
+		t.Errorf("checkFieldSize within the limit: %v", err)
+	}
+	if err := checkFieldSize([]string{"abc", "defg"}, 3); err == nil || !strings.HasPrefix(err.Error(), "field 2 has 4 bytes") {
+		t.Errorf("checkFieldSize = %v", err)
+	}
+	if got := renderCSV(t, "Name\nAnn\n", "{{range .}}{{.Name}}{{end}}", "--max-field-size", "1K"); got != "Ann" {
+		t.Errorf("got %q", got)
+	}
+}
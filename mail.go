@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// smtpPasswordEnv is the environment variable holding the SMTP password.
+const smtpPasswordEnv = "CSVPLATE_SMTP_PASSWORD"
+
+// mailer sends the rendered units as emails (--mail-to).
+type mailer struct {
+	// server is the SMTP server as host:port
+	server string
+	auth   smtp.Auth
+	from   string
+	// to and subject render the recipients and the subject of every unit
+	to, subject *template.Template
+	html        bool
+}
+
+// newMailer parses the SMTP server URL smtp://[user@]host[:port] (port 587 by default).
+// The password of the user is read from the CSVPLATE_SMTP_PASSWORD environment variable.
+func newMailer(server, from string) (*mailer, error) {
+	if !strings.Contains(server, "://") {
+		server = "smtp://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Scheme != "smtp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SMTP server %q (expected smtp://[user@]host[:port])", server)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "587"
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+	m := &mailer{server: net.JoinHostPort(u.Hostname(), port), from: from}
+	if u.User != nil {
+		m.auth = smtp.PlainAuth("", u.User.Username(), os.Getenv(smtpPasswordEnv), u.Hostname())
+	}
+	return m, nil
+}
+
+// message returns the email with its headers and the CRLF terminated body.
+func (m *mailer) message(to []string, subject, body string) []byte {
+	var b bytes.Buffer
+	contentType := "text/plain"
+	if m.html {
+		contentType = "text/html"
+	}
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	fmt.Fprintf(&b, "Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	b.WriteString(body)
+	return b.Bytes()
+}
+
+// recipients parses the rendered comma separated list of addresses.
+func recipients(list string) ([]string, error) {
+	addresses, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipients %q: %w", list, err)
+	}
+	to := make([]string, len(addresses))
+	for i, addr := range addresses {
+		to[i] = addr.Address
+	}
+	return to, nil
+}
+
+// sendMails renders and sends one email per unit (row or group).
+// A failing unit is reported and the others are still sent.
+// In dry-run mode the emails are rendered but not sent.
+func (a *app) sendMails(contentTmpl executor, units []unit) error {
+	m := a.mailer
+	var sent, failed int
+	report := func(to string, rows []map[string]any, err error) {
+		status := "sent"
+		if err != nil {
+			failed++
+			status = "error"
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", to, err)
+		} else {
+			sent++
+			if a.dryRun {
+				a.info("%s (not sent)\n", to)
+			} else {
+				a.info("%s\n", to)
+			}
+		}
+		if a.manifest != nil {
+			entry := manifestEntry{Output: "mailto:" + to, Rows: a.rowNumbers(rows), Status: status}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			a.manifest.Files = append(a.manifest.Files, entry)
+		}
+	}
+
+	if a.dryRun {
+		a.info("emails that would be sent to:\n")
+	} else {
+		a.info("emails sent to:\n")
+	}
+	var b strings.Builder
+	for _, u := range units {
+		// Render the recipients, the subject and the body
+		b.Reset()
+		if err := m.to.Execute(&b, u.nameDot()); err != nil {
+			report(u.name, u.rows, fmt.Errorf("render recipients: %w", err))
+			continue
+		}
+		toList := strings.TrimSpace(b.String())
+		to, err := recipients(toList)
+		if err != nil {
+			report(u.name, u.rows, err)
+			continue
+		}
+		b.Reset()
+		if err := m.subject.Execute(&b, u.nameDot()); err != nil {
+			report(toList, u.rows, fmt.Errorf("render subject: %w", err))
+			continue
+		}
+		subject := strings.TrimSpace(b.String())
+		b.Reset()
+		if err := contentTmpl.Execute(&b, u.data); err != nil {
+			report(toList, u.rows, fmt.Errorf("render body: %w", err))
+			continue
+		}
+		// Send the email
+		if !a.dryRun {
+			if err := smtp.SendMail(m.server, m.auth, m.from, to, m.message(to, subject, b.String())); err != nil {
+				report(toList, u.rows, fmt.Errorf("send: %w", err))
+				continue
+			}
+			if err := a.recordAudit(toList, "mail", u.rows); err != nil {
+				return err
+			}
+		}
+		report(toList, u.rows, nil)
+	}
+	if a.dryRun {
+		a.info("%d emails rendered, %d failed\n", sent, failed)
+	} else {
+		a.info("%d emails sent, %d failed\n", sent, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d emails could not be sent", failed)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	t.Setenv(smtpPasswordEnv, "secret")
+	m, err := newMailer("smtp://bob@mail.example.com", "Bob <bob@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.server != "mail.example.com:587" || m.auth == nil {
+		t.Errorf("mailer = %+v", m)
+	}
+	if m, err := newMailer("localhost:25", "bob@example.com"); err != nil || m.server != "localhost:25" || m.auth != nil {
+		t.Errorf("mailer = %+v, %v", m, err)
+	}
+	if _, err := newMailer("http://mail.example.com", "bob@example.com"); err == nil {
+		t.Error("http server: no error")
+	}
+	if _, err := newMailer("localhost", "bob"); err == nil {
+		t.Error("invalid sender: no error")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	to, err := recipients("Ann <ann@example.com>, bob@example.com")
+	if want := []string{"ann@example.com", "bob@example.com"}; err != nil || !reflect.DeepEqual(to, want) {
+		t.Errorf("recipients = %q, %v", to, err)
+	}
+	if _, err := recipients("ann@"); err == nil {
+		t.Error("invalid address: no error")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	m := &mailer{from: "bob@example.com", html: true}
+	msg := string(m.message([]string{"a@x.org", "b@x.org"}, "Café", "Hi\nthere\n"))
+	head, body, ok := strings.Cut(msg, "\r\n\r\n")
+	if !ok || body != "Hi\r\nthere\r\n" {
+		t.Errorf("body = %q", body)
+	}
+	for _, h := range []string{"To: a@x.org, b@x.org", "Subject: =?utf-8?q?Caf=C3=A9?=", "Content-Type: text/html; charset=utf-8"} {
+		if !strings.Contains(head, h+"\r\n") {
+			t.Errorf("header %q missing in\n%s", h, head)
+		}
+	}
+}
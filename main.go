@@ -19,55 +19,376 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 	"unicode/utf8"
 
-	"github.com/go-sprout/sprout"
-	"github.com/go-sprout/sprout/group/all"
 	"github.com/kpym/utf8reader"
 	"github.com/spf13/pflag"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
 )
 
 var version = "dev"
 
 type app struct {
 	csvPath      string
+	stdinSep     string
+	stdinParts   []string
 	templatePath string
+	partials     []string
+	entry        string
+	templateDir  string
+	library      []templateText
 	outPath      string
+	outLiteral   bool
+	transpose    bool
+	pivot        *pivot
+	archivePath  string
+	latestLink   string
+	latestTmpl   *template.Template
+	latest       map[string]string
+	keepLast     int
+	keepGlob     string
+	splitSize    int
+	chunk        int
+	labels       *labelSheet
+	mailer       *mailer
+	mailTo       string
+	mailSubject  string
+	execCommand  string
+	execJobs     int
+	outputs      []string
+	written      []string
+	archive      *archive
+	manifestPath string
+	manifest     *manifest
 	counter      string
+	localCounter string
+	counterStart int
+	counterStep  int
+	counterFmt   string
 	keep         keepFunk
+	skipRows     int
+	skipFooter   int
 	noHeader     bool
+	autoHeader   bool
 	force        bool
+	append       bool
+	dryRun       bool
+	verbosity    int
+	progress     bool
+	check        bool
+	ifChanged    bool
+	nameByHash   bool
+	hashExt      string
+	strictUTF8   bool
+	maxFieldSize int
+	onCollision  string
+	dupHeaders   string
+	stripCells   bool
+	updated      int
+	unchanged    int
 	csvSep       rune
+	csvSepMulti  string
+	comment      rune
+	lazyQuotes   bool
+	allowRagged  bool
+	trim         bool
+	collapse     bool
+	encrypt      encrypter
+	outEncoding  encoding.Encoding
+	crlf         bool
+	bom          bool
+	asciiOnly    bool
+	asciiSymbols bool
+	charset      string
+	charsetEnc   encoding.Encoding
+	postCommand  string
+	pretty       string
+	wrap         int
+	noWrapMarker string
+	bannerText   string
+	banner       string
+	html         bool
+	engine       string
+	strict       bool
+	leftDelim    string
+	rightDelim   string
+	mask         *maskPolicy
+	auditPath    string
+	audit        *auditLog
+	headers      []string
+	vars         map[string]string
+	pseudoKey    []byte
+	allowEnv     bool
+	filter       string
+	offset       int
+	unique       bool
+	uniqueBy     []string
+	limit        int
+	allowFuncs   []string
+	funcsGroups  []string
+	noFuncs      bool
+	funcsScript  string
+	denyFuncs    []string
+	sortKeys     []sortKey
+	groupBy      string
+	fillDown     []string
+	totals       []total
+	columns      []column
+	renames      map[string]string
+	rawOpen      string
+	rawClose     string
+	inferTypes   bool
+	naValues     []string
+	round        roundings
+	schema       *schema
+	holidays     *holidays
+	copyExt      []string
+	noNested     bool
+	jsonColumns  []string
+	binaryCols   []string
 }
 
 var prehelp = `csvplate (version: ` + version + `): a CSV templated file generator
 
 Usage: csvplate [options]
+       csvplate manifest-diff old.json new.json
 Options:
 `
 var posthelp = `
 Mode of operation:
   If the output file name contains template expressions ({{...}}), one file per row
   will be created, else a single file will be created with all rows.
-  In single file mode, the dot (.) in the template is a slice of objects (one per row).
-  In per-row mode, the dot (.) in the template is a single object (the current row).
+  With --out-literal, the output file name is used as is (even with {{ or ${) for a single file.
+  The output file name can also refer to fields as ${Name}-${_index_}.txt, without quoting
+  issues nor function calls; ${Key} is the group key with --group-by.
+  In single file mode, the dot (.) in the template is a slice of objects (one per row),
+  with .Count the number of rows; headers gives the column names and sumBy, avgBy, minBy,
+  maxBy "col" . aggregate a column of the rows (or of a group .Rows), countBy counts its values.
+  In per-row mode, the dot (.) in the template is a single object (the current row), with
+  its position .Index (from 0), .IsFirst, .IsLast, the neighbor rows .Prev, .Next and all rows .All.
   The first line of the CSV is assumed to be the header line and will be used as field names,
   except if the --noheader flag is set in which case the fields will be named C1, C2, ...
+  With --skip-rows and --skip-footer, the first and last lines are ignored before --skip
+  is applied and the header is read.
+  Repeated header names are reported (the last column wins), or with --duplicate-headers
+  rejected (error) or renamed Name_2, Name_3... (rename).
+  With --header auto, the first line is a header only if its cells are non-empty, unique
+  and neither numbers nor dates; the decision is reported on stderr.
+  Byte order marks and zero-width characters are removed from the headers, and with
+  --strip-invisible from the data cells too; the number of cleaned cells is reported on stderr.
   The field name specified with --counter will contain the row number (starting at 1).
+  When grouping, the field named by --local-counter contains the row number in its group.
+  With --transpose, the lines and the columns of the CSV are swapped (the first column then gives
+  the headers). With --pivot key=Month,value=Amount, every row gives one row per column (from the
+  second one, or from=Jan): the previous columns, Month (the column name) and Amount (its cell).
+  With --rename old=new (or a CSV file of old,new lines), the columns are renamed, if present,
+  before anything else, so that one template can read CSV files with different headers.
+  With --columns, only the listed columns are kept, in this order; a column given as
+  name:newname is renamed.
+  With --fill-down, the empty cells of the listed columns take the value above them,
+  before the --schema validation (so a filled required cell is not missing).
+  With --filter, only the rows for which the expression is true are rendered;
+  the expression is evaluated as a template action with the row as dot.
+  With --unique, the rows identical to a previous one are dropped (after --filter), and with
+  --unique-by col1,col2 the rows having the same values in these columns.
+  With --offset and --limit (or --rows FROM:TO), only a slice of the filtered and sorted rows
+  is rendered, e.g. --limit 5 to try a template on the first rows.
+  With --sort-by, the rows are sorted before rendering; each key is column[:num][:desc].
+  With --group-by, the rows are grouped by the value of a column: in single file mode
+  the dot is a slice of groups, in per-row mode one file is created per group.
+  Each group has a .Key (the column value), .Rows (its rows) and .First (first row).
+  The counters start at --counter-start, increase by --counter-step and, with --counter-format
+  (e.g. invoice_%04d), are formatted strings; the local counter restarts in every group.
+  With --infer-types, values looking like integers, floats, booleans or ISO dates
+  are converted to int, float64, bool or time.Time (the counter is then an int).
+  With --na, the listed values (e.g. NULL,N/A,-) are read as empty cells, or as nil with --infer-types.
+  With --round, the float values (inferred or from the schema) are rounded, e.g. 2 for all
+  columns or Amount=2:half-even for one column; the modes are half-up (default), half-even, down, up.
+  With --schema, the cells are parsed and validated against a YAML description of
+  the columns (type, date layout, required, allowed values); all failing cells are
+  reported with their line number.
+  The cells of the --json-columns columns (or of schema type json) are parsed as JSON.
+  The cells of the --binary-columns columns (or of schema type base64) are decoded from
+  base64 to bytes, written unchanged in the output with {{bytes .column}}.
+  Fields with dotted names (e.g. address.city) are nested, so the templates can use
+  .address.city, unless --no-nested is set.
+  With --totals (e.g. Amount=sum,Qty=avg), a synthetic row holding the totals, with
+  the field _is_total_ set to true, is appended to each group and, in single file mode,
+  to all rows. The aggregations are sum, avg, min, max and count.
+  The paginate function splits the rows in pages: {{range paginate 20 .}} gives pages with
+  .Number, .Total, .Rows, .First and .Last (row positions), .Count, .IsFirst and .IsLast.
+  For grid layouts, {{range chunk 3 .}} gives the rows by groups of 3, window 2 the
+  sliding pairs of consecutive rows, and zip the rows of several lists side by side.
+  To avoid splitting sections between pages, countLines and estimateLines (at a width)
+  measure a text, pageBreak "html"|"latex"|"text" returns a page break, and pager 60
+  tracks the used lines: {{if $p.Need 12}}{{pageBreak "latex"}}{{end}} before a section.
+  For exact money computations, decAdd, decSub, decMul and decDiv work on decimals (not float64)
+  and decRound 2 formats the result with exactly 2 decimals: {{decMul .Price .Qty | decRound 2}}.
+  convertUnit "in" "cm" converts lengths (mm..km, in, ft, yd, mi, nmi), masses (mg..t, oz, lb),
+  temperatures (C, F, K) and data sizes (bit, B, kB..PB, KiB..PiB); humanSize "iec"|"si" formats
+  a number of bytes (1.5 MiB or 1.6 MB).
+  geoDistance lat1 lon1 lat2 lon2 gives the distance in km between two points, formatDMS "lat"|"lon"
+  formats decimal degrees as 48°51'23.8"N and parseDMS converts such a coordinate back to degrees.
+  formatAddress .Country . lays out the name, street, postcode, city and state fields of the row
+  in the order of the country postal addresses (US, GB, JP... and postcode before city by default).
+  salutation .Locale .Gender .Last gives the letter greeting (Dear Ms. Smith, Sehr geehrter Herr Weber...),
+  nameCase fixes the case of a name (Jean-Luc O'Brien) and fullName .Locale .First .Last orders the names.
+  addBusinessDays 10 .Date skips the week-ends and the --holidays (a file of YYYY-MM-DD or yearly
+  MM-DD dates, each with an optional name); isBusinessDay, isHoliday and holidayName test a date.
+  age .Birth gives the age in years today (or at a date given as second argument) and
+  humanDuration .Start gives the time elapsed since a date in words, like 3 years 2 months.
+  classify .Score "0:red,50:orange,80:green" gives the label of the highest threshold reached.
+  symbol .Status gives ✓ (true, yes, ok, pass...), ✗ (false, no, fail, error...), ⚠ (warning, pending...)
+  or ?, and OK, FAIL, WARN with --ascii-symbols.
+  diffText "inline"|"html"|"unified" .Old .New shows the changes between two texts, as [-old-]{+new+},
+  <del>old</del><ins>new</ins> or a unified line diff.
+  regexExtract "(?P<area>\\d{3})-(?P<num>\\d+)" .Phone returns the named groups of the match as a map.
+  levenshtein gives the edit distance of two texts, similarity their closeness from 0 to 1, and
+  closestMatch (list "Sales" "Marketing") .Dept the most similar candidate, for fuzzy lookups.
+  Every --set key=value adds (or overrides) the field key with this value in all rows.
+  With --strict, using a missing field in the content or output name templates is an
+  error instead of rendering <no value>.
+  With --profile name, the options of the profile (long flag names and values) are read
+  from the config file (--config, or csvplate.toml, csvplate.yaml in the current directory);
+  the options given on the command line take precedence.
+  The --csv-sep separator can use escapes (\t for tab) and have several characters (||).
+  With --lazy-quotes, badly quoted cells are read as is, and with --allow-ragged the rows
+  may have fewer cells (the missing ones are empty) or more cells (the extra ones are ignored).
+  With --trim, the whitespace around the headers and cells is removed, and with --collapse-spaces
+  the inner whitespace runs are also replaced by a single space.
+  With --comment '#', the CSV lines starting with # are ignored.
   If --csv or --template is omitted or empty, stdin is used.
+  With --stdin-sep '---', stdin holds the CSV, a '---' line, then the template, so both inputs
+  can be piped (e.g. from a heredoc).
   If --out is omitted or empty, stdout is used in single file mode.
-  If the output file already exists, an error is returned unless --force is set.
+  If the output file already exists, an error is returned unless --force is set,
+  or --append is set in which case the output is added at the end of the file.
+  Every output file is written to a temporary file renamed on success, so a rendering
+  error never leaves a partial file behind.
+  With --on-collision, rows rendering to the same output name are an error, get a -2, -3...
+  suffix, or are appended to the same file; by default the later ones overwrite (--force).
+  With --name-by-hash, every row (or group) gives a file named by the SHA-256 of its content
+  and the --hash-ext extension, in the --out directory; identical outputs are written once.
+  With --if-changed, the outputs are rendered in memory and an existing file is only
+  rewritten if its content changed (unchanged files do not need --force).
+  With --banner, the rendered template (e.g. "Generated from {{sourceFile}} on {{now}}")
+  is added as a comment at the top of every output, in the comment style of its
+  extension (//, #, %, --, ;, <!-- -->, /* */), after a shebang line, an XML declaration
+  or the <?php tag; it is omitted for unknown extensions.
+  With --wrap, the paragraphs (separated by empty lines) of each output are re-wrapped
+  to the given width, except between two --no-wrap-marker lines (which are removed).
+  With --pretty json|yaml, each output is parsed and re-indented; an output that
+  can not be parsed is an error.
+  With --postprocess, each output is piped through the shell command, run with sh -c (e.g. gofmt,
+  'jq -r ".items[] | .name"'), before being written; a failing command fails the output.
+  With --out-encoding (e.g. latin1, windows-1252) the outputs are encoded from UTF-8,
+  a character missing in the encoding is an error; --crlf makes the line endings CRLF.
+  With --charset-check (ascii, latin1...), an output with characters outside the charset is
+  an error; --ascii-only first transliterates the outputs to ASCII (é gives e, ß gives ss).
+  With --bom, every output starts with a UTF-8 byte order mark (the byte order marks
+  of the inputs are always removed).
+  With --mail-to (e.g. '{{.Email}}'), every row (or group) is sent as an email through
+  the --smtp server instead of being written; --mail-subject is a template too and --html
+  sends HTML emails. With --dry-run the emails are rendered but not sent.
+  With --exec, a shell command is run for every generated (not unchanged) file, once all
+  are written, {} being replaced by the file path (e.g. --exec 'pdflatex {}'); --exec-jobs
+  runs several commands in parallel. All commands are run and the failures reported.
+  With --labels COLSxROWS, the template renders one label (the dot is the row) and the
+  labels are tiled in printable A4 sheets (HTML, or LaTeX for a .tex output or with
+  --label-format latex) with the --label-margin page margin.
+  With --chunk 500 (per-row mode), the rows are rendered by batches of 500: the dot of the template
+  is the rows of the batch, and the output name gets the batch as .Number, .Total, .First, .Last.
+  The --local-counter field then numbers the rows within their batch.
+  With --split-size (single file mode), the rows are split in consecutive chunks, each
+  rendered with the template in its own file (out.1.txt, out.2.txt...) of at most this size
+  (the size of the rendered template, before the banner, BOM, encoding, CRLF and encryption).
+  With --latest-link latest.pdf, a symbolic link in the directory of the outputs points at the
+  last one written; a link name template (e.g. 'reports/{{.Key}}/latest.pdf') gives one link per group
+  (fields are not available in single file mode). For a template tree, the link points at the output root.
+  With --keep-last N, after a successful run, only the N most recent files matching the output
+  name (its template expressions matching anything, or --keep-pattern) are kept in every output
+  directory, so recurring reports need no cleanup job; the files just written are always kept.
+  With --name-by-hash, the files matching the hash names are pruned; a template tree can not be pruned.
+  With --archive, the output files are written as entries of a single .zip, .tar
+  or .tar.gz archive (replaced only with --force) instead of on disk.
+  With --check, the templates (content, output name, partials, tree files) are only
+  checked: all the fields they use that are not CSV columns are reported (go engine only).
+  With --manifest, a JSON file lists every output with its row numbers, byte size,
+  SHA-256 checksum and status (created, overwritten, skipped or error), and the exit code.
+  csvplate manifest-diff old.json new.json lists the outputs added (+), removed (-),
+  changed (~, other checksum) or failed (!) between the runs of two manifests.
+  The informational messages (like the list of saved files) are printed on stderr:
+  --quiet removes them, --verbose adds details and --progress replaces the list of files
+  by a live counter.
+  The exit code is 0 on success, 2 if some files were not overwritten, 3 if some files
+  could not be rendered (dry-run) and 1 for any other error.
+  With --dry-run, everything is rendered but nothing is written: the files that would
+  be created or overwritten are listed, and all rendering errors are reported.
+  With --max-field-size, a CSV cell larger than the given size is an error.
+  The inputs not in UTF-8 are transcoded, except with --strict-utf8 where invalid
+  UTF-8 sequences are an error reporting their byte offsets.
   If --csv or --template is not an existing file, it is treated as the actual content.
+  If several --template are given (or a glob matching several files), the first one is
+  rendered and the others can define partials, called with {{template "name" .}}.
+  The partials are named after their file base name, two files can not share one.
+  With --template-dir, every file of the directory is available as a template named
+  after its relative path, in the content and the output name templates.
+  With --entry, the named template ({{define "name"}}) is rendered instead.
+  With --delims, the template delimiters are changed (e.g. "[[,]]") in all templates,
+  including the output file name.
+  If --template is a directory, the whole tree is rendered for every row (or group)
+  into the --out directory: every file is a template and the file and directory
+  names may contain template expressions, as --out itself.
+  A tree file whose first line is {{/* skip-if: condition */}} is not generated for
+  the rows where the condition is true.
+  Executable tree files give executable outputs; the .csvplatemode file at the root
+  of the tree can set the permissions with 'pattern mode' lines (e.g. 'bin/* 0755'),
+  also kept by the --archive entries.
+  Files with a --copy-ext extension, or matching a pattern listed in the .csvplatecopy
+  file at the root of the tree, are copied verbatim instead of being rendered (without
+  banner, BOM, encoding, line ending conversion nor post-processing, only --encrypt-out).
+  With --raw-delims (e.g. "<raw> </raw>"), the text between the two markers is
+  output verbatim, even if it contains template delimiters.
+  With --mask-policy, the listed sensitive columns are masked (redact, hash or partial)
+  before rendering, unless --unmasked is set. The hash strategy is an HMAC keyed by the
+  --mask-key-env environment variable (required). A rule whose columns are all missing
+  from the final header (e.g. renamed by --rename or --columns) is an error.
+  With --audit, one JSON line per output records which rows and columns it contains.
+  With --html, the content template is parsed with html/template (contextual escaping).
+  With --engine mustache or pongo2 (Jinja like), the content templates use that language;
+  the dot is the row, the group (Key, Rows, First) or, in single file mode, rows.
+  With --encrypt-out, every output is encrypted with age (recipients file) or gpg.
   The template functions from Sprout are available in the templates.
+  With --funcs-groups (e.g. strings,maths,time), only the functions of these Sprout groups are
+  loaded (checksum, conversion, encoding, env, filesystem, maps, network, numeric or maths,
+  random, reflect, regexp, semver, slices, std, strings, time, uniqueid); --no-funcs loads none.
+  With --funcs script.star, the top-level functions of the Starlark script (except _private
+  ones) are template functions too; they get and return strings, numbers, lists and dicts.
+  With --allow-funcs only the listed functions are available; --deny-funcs removes
+  the listed functions (the absent ones are ignored, e.g. --deny-funcs env,exec,readFile).
+  The env and expandEnv functions, reading environment variables, are only available
+  with --allow-env.
+  The pseudonymize function returns a stable HMAC based pseudonym, keyed by the
+  content of the environment variable named by --pseudo-key-env.
 
 Examples:
   csvplate --csv data.csv --template template.txt --out output.txt
   csvplate -f -i data.csv -t template.txt -o output_{{.Name}}.txt
   csvplate -i data.csv -d ';' -s 2 -t template.txt
+  csvplate -i data.csv -t letter.tmpl -t 'partials/*.tmpl' -o letter.txt
   cat data.csv | csvplate -n -t template.txt
+  csvplate -i data.csv -t template.txt --filter 'ge (toInt .Age) 18'
+  csvplate -i projects.csv -t scaffold/ -o 'projects/{{.Name}}'
+  csvplate -i data.csv -t template.txt --columns Name,Email:Mail
+  csvplate -i data.csv -t template.txt --sort-by City,Amount:num:desc
+  csvplate -i data.csv -g Customer -t invoice.txt -o 'invoice_{{.Key}}.txt'
+  csvplate -i data.csv -t template.txt --set env=prod --set date=2024-01-31
+  csvplate -i data.csv --delims '[[,]]' -t template.tex -o 'letter_[[.Name]].tex'
 `
 
 // printHelp prints the help message to the default output.
@@ -82,14 +403,114 @@ func printHelp() {
 
 // newApp creates a new app instance using the command line arguments.
 func newApp() *app {
+	configPath := pflag.String("config", "", "Config file defining the profiles (default: csvplate.toml or csvplate.yaml)")
+	profile := pflag.String("profile", "", "Name of the config file profile providing the default options")
 	csvPath := pflag.StringP("csv", "i", "", "Path to input CSV file, or the CSV content itself")
-	templatePath := pflag.StringP("template", "t", "", "Path to Go template file, or the template content itself")
-	outPath := pflag.StringP("out", "o", "", "Output file path (may include template expressions)")
+	stdinSep := pflag.String("stdin-sep", "", "Line separating the CSV and the template, both read from stdin")
+	templatePaths := pflag.StringArrayP("template", "t", nil, "Path to Go template file (or glob), or the template content itself (repeatable)")
+	entry := pflag.StringP("entry", "e", "", "Name of the defined template to render (default: the first template)")
+	templateDir := pflag.String("template-dir", "", "Directory of library templates, available by file name")
+	outPath := pflag.StringP("out", "o", "", "Output file path (may include template expressions or ${field} references)")
+	outLiteral := pflag.Bool("out-literal", false, "Use --out as is, as a single output file name even if it contains {{ or ${")
+	manifestPath := pflag.String("manifest", "", "Write a JSON manifest of all outputs (rows, size, checksum, status) to this file")
+	mailTo := pflag.String("mail-to", "", "Send every row (or group) by email to these rendered recipients instead of writing files")
+	mailSubject := pflag.String("mail-subject", "", "Template of the email subject")
+	mailFrom := pflag.String("mail-from", "", "Sender address of the emails")
+	smtpServer := pflag.String("smtp", "", "SMTP server as smtp://[user@]host[:port] (password in CSVPLATE_SMTP_PASSWORD)")
+	execCommand := pflag.String("exec", "", "Shell command run for every generated file, {} being replaced by its path")
+	execJobs := pflag.Int("exec-jobs", 1, "Number of --exec commands run in parallel")
+	labels := pflag.String("labels", "", "Tile the rendered rows as labels in sheets of COLSxROWS (e.g. 3x8)")
+	labelMargin := pflag.String("label-margin", "10mm", "Page margin of the label sheets")
+	labelFormat := pflag.String("label-format", "", "Format of the label sheets: html or latex (default: from the output extension)")
+	chunk := pflag.Int("chunk", 0, "In per-row mode, render the rows by batches of this size, one output per batch")
+	splitSize := pflag.String("split-size", "", "In single file mode, split the output in files of at most this size (e.g. 10M or 10MB)")
+	latestLink := pflag.String("latest-link", "", "Symbolic link pointing at the last output (per link name, which may be a template)")
+	keepLast := pflag.Int("keep-last", 0, "After a successful run, keep only this number of most recent outputs in every output directory")
+	keepGlob := pflag.String("keep-pattern", "", "Glob of the files pruned by --keep-last (default: the --out file name, with * for the template expressions)")
+	archivePath := pflag.String("archive", "", "Write all outputs as entries of this .zip, .tar or .tar.gz file")
 	counter := pflag.StringP("counter", "c", "_index_", "The field name to use for the row counter")
+	localCounter := pflag.String("local-counter", "_local_", "The field name to use for the row counter within a group (or --chunk batch)")
+	counterStart := pflag.Int("counter-start", 1, "First value of the counters")
+	counterStep := pflag.Int("counter-step", 1, "Increment of the counters")
+	counterFmt := pflag.String("counter-format", "", "Printf format of the counters, e.g. %04d or INV-%04d (the counters are then strings)")
 	noHeader := pflag.BoolP("noheader", "n", false, "Treat CSV as having no header row")
+	header := pflag.String("header", "", "Whether the first CSV row is a header: yes, no or auto (detected)")
+	dupHeaders := pflag.String("duplicate-headers", "", "What to do with repeated header names: error or rename (Name_2, ...)")
+	stripCells := pflag.Bool("strip-invisible", false, "Remove the byte order marks and zero-width characters from the data cells too (always from the headers)")
 	skip := pflag.StringP("skip", "s", "", "Number of lines to skip or regex to match the first (header) line")
+	skipRows := pflag.Int("skip-rows", 0, "Number of leading lines (titles, export metadata) to ignore")
+	skipFooter := pflag.Int("skip-footer", 0, "Number of trailing lines (summary footer) to ignore")
 	force := pflag.BoolP("force", "f", false, "Overwrite existing output files")
-	csvSep := pflag.StringP("csv-sep", "d", ",", "CSV field separator")
+	appendOut := pflag.Bool("append", false, "Append to the existing output files")
+	dryRun := pflag.Bool("dry-run", false, "Render everything but write nothing, list the files that would be written")
+	verboseFlag := pflag.BoolP("verbose", "v", false, "Print detailed messages on stderr")
+	quietFlag := pflag.BoolP("quiet", "q", false, "Print no informational messages, only errors")
+	progress := pflag.Bool("progress", false, "Show a live counter of the rendered outputs instead of listing them")
+	check := pflag.Bool("check", false, "Only check that the fields used in the templates exist in the CSV")
+	nameByHash := pflag.Bool("name-by-hash", false, "Name every output by the SHA-256 of its content, in the --out directory")
+	hashExt := pflag.String("hash-ext", "", "Extension of the outputs named by --name-by-hash (e.g. .json)")
+	ifChanged := pflag.Bool("if-changed", false, "Only write the outputs whose content differs from the existing file")
+	onCollision := pflag.String("on-collision", "", "What to do when rows render to the same output name: error, append or suffix")
+	inferTypes := pflag.Bool("infer-types", false, "Convert numbers, booleans and ISO dates to typed values")
+	naValues := pflag.StringSlice("na", nil, "Comma separated list of values meaning a missing value (e.g. NULL,N/A,-)")
+	round := pflag.StringSlice("round", nil, "Round the float values, each rule as [column=]places[:half-up|half-even|down|up]")
+	schemaPath := pflag.String("schema", "", "YAML file describing the column types and constraints")
+	jsonColumns := pflag.StringSlice("json-columns", nil, "Comma separated list of columns containing JSON")
+	binaryCols := pflag.StringSlice("binary-columns", nil, "Comma separated list of columns containing base64 encoded binary data")
+	noNested := pflag.Bool("no-nested", false, "Do not nest the fields with dotted names")
+	transpose := pflag.Bool("transpose", false, "Swap the lines and the columns of the CSV (the first column gives the headers)")
+	pivotSpec := pflag.String("pivot", "", "Turn the columns into rows, as key=Month,value=Amount[,from=Jan] (from the second column by default)")
+	renames := pflag.StringArray("rename", nil, "Rename a column, as old=new or a CSV file of old,new lines (repeatable)")
+	columns := pflag.StringSlice("columns", nil, "Comma separated list of columns to keep, each as name[:newname]")
+	fillDownCols := pflag.StringSlice("fill-down", nil, "Comma separated list of columns where empty cells repeat the value above")
+	filter := pflag.String("filter", "", "Only render rows for which this template expression is true")
+	unique := pflag.Bool("unique", false, "Drop the duplicate rows (after --filter), keeping the first one")
+	uniqueBy := pflag.StringSlice("unique-by", nil, "Like --unique, but rows are duplicates if they have the same values in these columns")
+	offset := pflag.Int("offset", 0, "Skip this number of rows (after --filter and --sort-by)")
+	limit := pflag.Int("limit", 0, "Render at most this number of rows (after --filter and --sort-by)")
+	rowRange := pflag.String("rows", "", "Render only the rows FROM:TO (1-based, inclusive), e.g. 10:50")
+	sortBy := pflag.StringSlice("sort-by", nil, "Sort rows by these keys, each as column[:num][:desc]")
+	groupBy := pflag.StringP("group-by", "g", "", "Group the rows by this column (one output per group in per-row mode)")
+	totals := pflag.StringSlice("totals", nil, "Append a totals row, with aggregations given as column=sum|avg|min|max|count")
+	sets := pflag.StringArray("set", nil, "Add the field key=value to every row (repeatable)")
+	strictUTF8 := pflag.Bool("strict-utf8", false, "Fail on invalid UTF-8 input instead of transcoding it")
+	maxFieldSize := pflag.String("max-field-size", "", "Maximal size of a CSV cell, in bytes with an optional K, M or G suffix (and B, e.g. 512KB)")
+	csvSep := pflag.StringP("csv-sep", "d", ",", "CSV field separator, possibly several characters or escapes like \\t")
+	comment := pflag.String("comment", "", "Character starting the comment lines of the CSV, which are ignored (e.g. #)")
+	lazyQuotes := pflag.Bool("lazy-quotes", false, "Accept quotes in unquoted fields and non-doubled quotes in quoted fields")
+	allowRagged := pflag.Bool("allow-ragged", false, "Accept rows with a different number of cells (missing cells are empty)")
+	trim := pflag.Bool("trim", false, "Remove the leading and trailing whitespace of the headers and cells")
+	collapse := pflag.Bool("collapse-spaces", false, "Like --trim, and replace the inner whitespace runs by a single space")
+	delims := pflag.String("delims", "{{,}}", "Template delimiters, as left,right")
+	copyExt := pflag.StringSlice("copy-ext", nil, "Extensions of the tree files copied verbatim (e.g. png,jpg)")
+	rawDelims := pflag.String("raw-delims", "", "Markers of verbatim blocks in the template, as 'open close'")
+	engine := pflag.String("engine", "go", "Template language of the content templates: go, mustache or pongo2 (Jinja like)")
+	html := pflag.Bool("html", false, "Parse the content template with html/template (auto-escaping)")
+	strict := pflag.Bool("strict", false, "Fail on missing fields in the content and name templates")
+	holidaysPath := pflag.String("holidays", "", "File of the holidays (YYYY-MM-DD or yearly MM-DD, and a name) for the business day functions")
+	maskPolicyPath := pflag.String("mask-policy", "", "YAML file listing the columns to mask and how")
+	maskKeyEnv := pflag.String("mask-key-env", "", "Environment variable holding the key of the hash strategy of --mask-policy")
+	unmasked := pflag.Bool("unmasked", false, "Do not apply the --mask-policy")
+	auditPath := pflag.String("audit", "", "Append an audit record (JSON lines) for every output to this file")
+	funcsScript := pflag.String("funcs", "", "Starlark script whose top-level functions are added to the template functions")
+	funcsGroups := pflag.StringSlice("funcs-groups", nil, "Comma separated list of the Sprout function groups to load (e.g. strings,maths,time), all by default")
+	noFuncs := pflag.Bool("no-funcs", false, "Load no Sprout functions, only the csvplate ones")
+	allowFuncs := pflag.StringSlice("allow-funcs", nil, "Comma separated list of the only template functions available")
+	denyFuncs := pflag.StringSlice("deny-funcs", nil, "Comma separated list of template functions to remove")
+	allowEnv := pflag.Bool("allow-env", false, "Allow templates to read environment variables (env, expandEnv)")
+	pseudoKeyEnv := pflag.String("pseudo-key-env", "", "Environment variable holding the pseudonymize key")
+	bannerText := pflag.String("banner", "", "Template of a comment added at the top of every output (style from the extension)")
+	wrap := pflag.Int("wrap", 0, "Re-wrap the paragraphs of every output to this line width")
+	noWrapMarker := pflag.String("no-wrap-marker", "%nowrap", "Line marking the start and the end of a block not re-wrapped by --wrap")
+	pretty := pflag.String("pretty", "", "Re-indent every output as json or yaml (an invalid output is an error)")
+	postCommand := pflag.String("postprocess", "", "Shell command each output is piped through before writing (e.g. 'jq .')")
+	outEncodingName := pflag.String("out-encoding", "", "Encoding of the outputs (e.g. latin1, windows-1252), UTF-8 by default")
+	crlf := pflag.Bool("crlf", false, "Write the outputs with CRLF line endings")
+	asciiOnly := pflag.Bool("ascii-only", false, "Transliterate the outputs to ASCII, an output with other characters is an error")
+	asciiSymbols := pflag.Bool("ascii-symbols", false, "Make the symbol function return OK, FAIL and WARN instead of ✓, ✗ and ⚠")
+	charsetCheck := pflag.String("charset-check", "", "Fail the outputs with characters outside this charset (ascii, latin1...)")
+	bom := pflag.Bool("bom", false, "Start every output with a UTF-8 byte order mark")
+	encryptOut := pflag.String("encrypt-out", "", "Encrypt outputs: age:<recipients file> or gpg:<recipient>")
 	// keep the flags order
 	pflag.CommandLine.SortFlags = false
 	// in case of error do not display second time
@@ -110,13 +531,63 @@ func newApp() *app {
 		fmt.Fprintln(os.Stderr, "csvplate:", err)
 		os.Exit(1)
 	}
+	// Complete the options with the profile
+	if err := useProfile(pflag.CommandLine, *configPath, *profile); err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: profile:", err)
+		os.Exit(1)
+	}
 
-	sep, size := utf8.DecodeRuneInString(*csvSep)
-	if size == 0 || size != len(*csvSep) {
-		fmt.Fprintln(os.Stderr, "csvplate: --csv-sep must be a single UTF-8 character")
+	sep, sepMulti, err := parseSeparator(*csvSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --csv-sep value:", err)
 		os.Exit(1)
 	}
 
+	var commentChar rune
+	if *comment != "" {
+		var size int
+		commentChar, size = utf8.DecodeRuneInString(*comment)
+		switch {
+		case size != len(*comment):
+			fmt.Fprintln(os.Stderr, "csvplate: --comment must be a single UTF-8 character")
+			os.Exit(1)
+		case commentChar == sep || commentChar == '"' || commentChar == '\r' || commentChar == '\n':
+			fmt.Fprintln(os.Stderr, "csvplate: --comment must differ from the separator, the quote and the line breaks")
+			os.Exit(1)
+		}
+	}
+
+	verbosity := normal
+	switch {
+	case *verboseFlag && *quietFlag:
+		fmt.Fprintln(os.Stderr, "csvplate: --verbose conflicts with --quiet")
+		os.Exit(1)
+	case *verboseFlag:
+		verbosity = verbose
+	case *quietFlag:
+		verbosity = quiet
+	}
+
+	var autoHeader bool
+	switch *header {
+	case "", "yes":
+	case "no":
+		*noHeader = true
+	case "auto":
+		autoHeader = !*noHeader
+	default:
+		fmt.Fprintln(os.Stderr, "csvplate: --header must be yes, no or auto")
+		os.Exit(1)
+	}
+	if *noHeader && *header == "yes" {
+		fmt.Fprintln(os.Stderr, "csvplate: --noheader conflicts with --header yes")
+		os.Exit(1)
+	}
+
+	if *skipRows < 0 || *skipFooter < 0 {
+		fmt.Fprintln(os.Stderr, "csvplate: --skip-rows and --skip-footer must be positive")
+		os.Exit(1)
+	}
 	keep := noSkip()
 	if *skip != "" {
 		if n, err := strconv.Atoi(*skip); err == nil {
@@ -130,15 +601,430 @@ func newApp() *app {
 		}
 	}
 
+	var maxField int
+	if *maxFieldSize != "" {
+		maxField, err = parseSize(*maxFieldSize)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --max-field-size value:", err)
+			os.Exit(1)
+		}
+	}
+
+	var splitBytes int
+	if *splitSize != "" {
+		splitBytes, err = parseSize(*splitSize)
+		if err == nil && splitBytes == 0 {
+			err = fmt.Errorf("size must be positive")
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --split-size value:", err)
+			os.Exit(1)
+		}
+	}
+
+	if *chunk < 0 || *chunk > 0 && *groupBy != "" {
+		fmt.Fprintln(os.Stderr, "csvplate: --chunk must be positive and can not be used with --group-by")
+		os.Exit(1)
+	}
+
+	var labelSheet *labelSheet
+	if *labels != "" {
+		labelSheet, err = parseLabels(*labels, *labelMargin, *labelFormat)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --labels value:", err)
+			os.Exit(1)
+		}
+	}
+
+	cols, err := parseColumns(*columns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --columns value:", err)
+		os.Exit(1)
+	}
+
+	sortKeys, err := parseSortKeys(*sortBy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --sort-by value:", err)
+		os.Exit(1)
+	}
+
+	rounds, err := parseRoundings(*round)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --round value:", err)
+		os.Exit(1)
+	}
+	if *rowRange != "" {
+		if pflag.CommandLine.Changed("offset") || pflag.CommandLine.Changed("limit") {
+			fmt.Fprintln(os.Stderr, "csvplate: --rows conflicts with --offset and --limit")
+			os.Exit(1)
+		}
+		*offset, *limit, err = parseRowRange(*rowRange)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --rows value:", err)
+			os.Exit(1)
+		}
+	}
+	if *offset < 0 || *limit < 0 {
+		fmt.Fprintln(os.Stderr, "csvplate: --offset and --limit must be positive")
+		os.Exit(1)
+	}
+	var piv *pivot
+	if *pivotSpec != "" {
+		if piv, err = parsePivot(*pivotSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "csvplate: --pivot: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	renameMap, err := parseRenames(*renames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --rename value:", err)
+		os.Exit(1)
+	}
+	tots, err := parseTotals(*totals)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvplate: invalid --totals value:", err)
+		os.Exit(1)
+	}
+
+	vars := make(map[string]string, len(*sets))
+	for _, kv := range *sets {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			fmt.Fprintf(os.Stderr, "csvplate: invalid --set value %q (expected key=value)\n", kv)
+			os.Exit(1)
+		}
+		vars[key] = value
+	}
+
+	if *counterStep == 0 {
+		fmt.Fprintln(os.Stderr, "csvplate: --counter-step must not be 0")
+		os.Exit(1)
+	}
+	if *counterFmt != "" && strings.Contains(fmt.Sprintf(*counterFmt, 1), "%!") {
+		fmt.Fprintln(os.Stderr, "csvplate: --counter-format must format a single integer, like %04d")
+		os.Exit(1)
+	}
+
+	if *noFuncs && len(*funcsGroups) > 0 {
+		fmt.Fprintln(os.Stderr, "csvplate: --no-funcs and --funcs-groups are mutually exclusive")
+		os.Exit(1)
+	}
+
+	switch *engine {
+	case "go":
+	case "mustache", "pongo2":
+		if *entry != "" || *rawDelims != "" {
+			fmt.Fprintf(os.Stderr, "csvplate: --entry and --raw-delims need the go engine, not %s\n", *engine)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "csvplate: --engine must be go, mustache or pongo2")
+		os.Exit(1)
+	}
+
+	switch *dupHeaders {
+	case "", "error", "rename":
+	default:
+		fmt.Fprintln(os.Stderr, "csvplate: --duplicate-headers must be error or rename")
+		os.Exit(1)
+	}
+
+	switch *onCollision {
+	case "", "error", "append", "suffix":
+	default:
+		fmt.Fprintln(os.Stderr, "csvplate: --on-collision must be error, append or suffix")
+		os.Exit(1)
+	}
+
+	if *pretty != "" && *pretty != "json" && *pretty != "yaml" {
+		fmt.Fprintln(os.Stderr, "csvplate: --pretty must be json or yaml")
+		os.Exit(1)
+	}
+
+	leftDelim, rightDelim, ok := strings.Cut(*delims, ",")
+	if !ok || leftDelim == "" || rightDelim == "" {
+		fmt.Fprintln(os.Stderr, "csvplate: --delims must be of the form left,right")
+		os.Exit(1)
+	}
+	out := *outPath
+	if !*outLiteral {
+		var err error
+		if out, err = expandFields(out, leftDelim, rightDelim); err != nil {
+			fmt.Fprintf(os.Stderr, "csvplate: --out: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var rawOpen, rawClose string
+	if *rawDelims != "" {
+		markers := strings.Fields(*rawDelims)
+		if len(markers) != 2 {
+			fmt.Fprintln(os.Stderr, "csvplate: --raw-delims must be two markers separated by a space")
+			os.Exit(1)
+		}
+		rawOpen, rawClose = markers[0], markers[1]
+	}
+
+	var sch *schema
+	if *schemaPath != "" {
+		sch, err = loadSchema(*schemaPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate:", err)
+			os.Exit(1)
+		}
+	}
+
+	// The first template is the main one, the others only define partials
+	var templatePath string
+	var partials []string
+	for _, p := range *templatePaths {
+		matches := expandGlob(p, leftDelim)
+		if templatePath == "" && len(matches) > 0 {
+			templatePath, matches = matches[0], matches[1:]
+		}
+		partials = append(partials, matches...)
+	}
+
+	var hols *holidays
+	if *holidaysPath != "" {
+		hols, err = loadHolidays(*holidaysPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate:", err)
+			os.Exit(1)
+		}
+	}
+
+	var mask *maskPolicy
+	if *maskPolicyPath != "" && !*unmasked {
+		mask, err = loadMaskPolicy(*maskPolicyPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate:", err)
+			os.Exit(1)
+		}
+		if *maskKeyEnv != "" {
+			mask.key = []byte(os.Getenv(*maskKeyEnv))
+		}
+		if mask.hashes() && len(mask.key) == 0 {
+			fmt.Fprintln(os.Stderr, "csvplate: the hash strategy of --mask-policy needs a key (--mask-key-env)")
+			os.Exit(1)
+		}
+	}
+
+	var pseudoKey []byte
+	if *pseudoKeyEnv != "" {
+		pseudoKey = []byte(os.Getenv(*pseudoKeyEnv))
+		if len(pseudoKey) == 0 {
+			fmt.Fprintf(os.Stderr, "csvplate: environment variable %s (--pseudo-key-env) is empty\n", *pseudoKeyEnv)
+			os.Exit(1)
+		}
+	}
+
+	if *nameByHash {
+		if *appendOut || *encryptOut != "" {
+			fmt.Fprintln(os.Stderr, "csvplate: --name-by-hash conflicts with --append and --encrypt-out")
+			os.Exit(1)
+		}
+		// An existing output of the same name has the same content
+		*ifChanged = true
+		if out == "" {
+			out = "."
+		}
+	}
+	if *appendOut && *ifChanged {
+		fmt.Fprintln(os.Stderr, "csvplate: --append conflicts with --if-changed")
+		os.Exit(1)
+	}
+
+	var outEncoding encoding.Encoding
+	if *outEncodingName != "" {
+		outEncoding, err = outputEncoding(*outEncodingName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --out-encoding value:", err)
+			os.Exit(1)
+		}
+	}
+
+	if *bom && outEncoding != nil && outEncoding != unicode.UTF8 {
+		fmt.Fprintln(os.Stderr, "csvplate: --bom needs a UTF-8 output (--out-encoding)")
+		os.Exit(1)
+	}
+
+	if *execCommand != "" && *archivePath != "" {
+		fmt.Fprintln(os.Stderr, "csvplate: --exec can not run on the entries of an --archive")
+		os.Exit(1)
+	}
+	if *keepLast < 0 || *keepLast > 0 && *archivePath != "" {
+		fmt.Fprintln(os.Stderr, "csvplate: --keep-last must be positive and can not prune the entries of an --archive")
+		os.Exit(1)
+	}
+	if *latestLink != "" {
+		switch {
+		case *archivePath != "":
+			fmt.Fprintln(os.Stderr, "csvplate: --latest-link can not point at the entries of an --archive")
+			os.Exit(1)
+		case *mailTo != "":
+			fmt.Fprintln(os.Stderr, "csvplate: --latest-link needs output files, not emails (--mail-to)")
+			os.Exit(1)
+		case (out == "" || out == "-") && !*nameByHash:
+			fmt.Fprintln(os.Stderr, "csvplate: --latest-link needs an output file (--out)")
+			os.Exit(1)
+		}
+	}
+
+	var mailer *mailer
+	if *mailTo != "" {
+		if *smtpServer == "" || *mailFrom == "" || *mailSubject == "" {
+			fmt.Fprintln(os.Stderr, "csvplate: --mail-to needs --smtp, --mail-from and --mail-subject")
+			os.Exit(1)
+		}
+		mailer, err = newMailer(*smtpServer, *mailFrom)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate:", err)
+			os.Exit(1)
+		}
+		mailer.html = *html
+	}
+
+	charset := *charsetCheck
+	if *asciiOnly {
+		if charset != "" && charset != "ascii" {
+			fmt.Fprintln(os.Stderr, "csvplate: --ascii-only conflicts with --charset-check", charset)
+			os.Exit(1)
+		}
+		charset = "ascii"
+	}
+	var charsetEncoding encoding.Encoding
+	if charset != "" && charset != "ascii" {
+		charsetEncoding, err = outputEncoding(charset)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --charset-check value:", err)
+			os.Exit(1)
+		}
+	}
+
+	var encrypt encrypter
+	if *encryptOut != "" {
+		if *appendOut {
+			fmt.Fprintln(os.Stderr, "csvplate: --append cannot extend encrypted outputs (--encrypt-out)")
+			os.Exit(1)
+		}
+		if *ifChanged {
+			fmt.Fprintln(os.Stderr, "csvplate: --if-changed cannot compare encrypted outputs (--encrypt-out)")
+			os.Exit(1)
+		}
+		encrypt, err = newEncrypter(*encryptOut)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate: invalid --encrypt-out value:", err)
+			os.Exit(1)
+		}
+	}
+
 	return &app{
 		csvPath:      *csvPath,
-		templatePath: *templatePath,
-		outPath:      *outPath,
+		stdinSep:     *stdinSep,
+		templatePath: templatePath,
+		partials:     partials,
+		entry:        *entry,
+		templateDir:  *templateDir,
+		outPath:      out,
+		outLiteral:   *outLiteral,
+		transpose:    *transpose,
+		pivot:        piv,
+		archivePath:  *archivePath,
+		latestLink:   *latestLink,
+		keepLast:     *keepLast,
+		keepGlob:     *keepGlob,
+		splitSize:    splitBytes,
+		chunk:        *chunk,
+		labels:       labelSheet,
+		mailer:       mailer,
+		mailTo:       *mailTo,
+		mailSubject:  *mailSubject,
+		execCommand:  *execCommand,
+		execJobs:     *execJobs,
+		manifestPath: *manifestPath,
 		counter:      *counter,
+		localCounter: *localCounter,
+		counterStart: *counterStart,
+		counterStep:  *counterStep,
+		counterFmt:   *counterFmt,
 		keep:         keep,
+		skipRows:     *skipRows,
+		skipFooter:   *skipFooter,
 		noHeader:     *noHeader,
+		autoHeader:   autoHeader,
 		force:        *force,
+		append:       *appendOut,
+		dryRun:       *dryRun,
+		verbosity:    verbosity,
+		progress:     *progress,
+		check:        *check,
+		ifChanged:    *ifChanged,
+		nameByHash:   *nameByHash,
+		hashExt:      *hashExt,
+		strictUTF8:   *strictUTF8,
+		maxFieldSize: maxField,
+		onCollision:  *onCollision,
+		dupHeaders:   *dupHeaders,
+		stripCells:   *stripCells,
 		csvSep:       sep,
+		csvSepMulti:  sepMulti,
+		comment:      commentChar,
+		lazyQuotes:   *lazyQuotes,
+		allowRagged:  *allowRagged,
+		trim:         *trim || *collapse,
+		collapse:     *collapse,
+		encrypt:      encrypt,
+		outEncoding:  outEncoding,
+		crlf:         *crlf,
+		bom:          *bom,
+		asciiOnly:    *asciiOnly,
+		asciiSymbols: *asciiSymbols,
+		charset:      charset,
+		charsetEnc:   charsetEncoding,
+		postCommand:  *postCommand,
+		pretty:       *pretty,
+		wrap:         *wrap,
+		noWrapMarker: *noWrapMarker,
+		bannerText:   *bannerText,
+		html:         *html,
+		engine:       *engine,
+		strict:       *strict,
+		leftDelim:    leftDelim,
+		rightDelim:   rightDelim,
+		mask:         mask,
+		auditPath:    *auditPath,
+		vars:         vars,
+		pseudoKey:    pseudoKey,
+		allowEnv:     *allowEnv,
+		filter:       *filter,
+		offset:       *offset,
+		unique:       *unique || len(*uniqueBy) > 0,
+		uniqueBy:     *uniqueBy,
+		limit:        *limit,
+		allowFuncs:   *allowFuncs,
+		funcsGroups:  *funcsGroups,
+		noFuncs:      *noFuncs,
+		funcsScript:  *funcsScript,
+		denyFuncs:    *denyFuncs,
+		sortKeys:     sortKeys,
+		groupBy:      *groupBy,
+		fillDown:     *fillDownCols,
+		totals:       tots,
+		columns:      cols,
+		renames:      renameMap,
+		rawOpen:      rawOpen,
+		rawClose:     rawClose,
+		inferTypes:   *inferTypes,
+		naValues:     *naValues,
+		round:        rounds,
+		schema:       sch,
+		holidays:     hols,
+		copyExt:      *copyExt,
+		noNested:     *noNested,
+		jsonColumns:  *jsonColumns,
+		binaryCols:   *binaryCols,
 	}
 }
 
@@ -198,12 +1084,31 @@ func skipLines(text string, first keepFunk) string {
 	}
 }
 
+// dropLastLines removes the last n lines of the text (the final line break is not a line).
+func dropLastLines(text string, n int) string {
+	if n <= 0 {
+		return text
+	}
+	text = strings.TrimRight(text, "\r\n")
+	for ; n > 0 && text != ""; n-- {
+		idx := strings.LastIndexByte(text, '\n')
+		if idx < 0 {
+			return ""
+		}
+		text = strings.TrimRight(text[:idx], "\r\n")
+	}
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
+
 // run executes the application logic.
-// if the output path contains template expressions, one file per row is created,
+// if the output path contains template expressions, one file per row (or group) is created,
 // else a single file is created.
-func (a *app) run() error {
-	if a.csvPath == "" && a.templatePath == "" {
-		return errors.New("one of --csv or --template is required")
+func (a *app) run() (err error) {
+	if a.csvPath == "" && a.templatePath == "" && a.stdinSep == "" {
+		return errors.New("one of --csv or --template (or --stdin-sep) is required")
 	}
 	if a.csvPath == "" {
 		a.csvPath = "-"
@@ -215,47 +1120,246 @@ func (a *app) run() error {
 		a.outPath = "-"
 	}
 
-	// Get the sprout functions to use in the templates
-	funcs, err := sproutFuncMap()
+	// Open the audit log
+	if a.auditPath != "" {
+		audit, err := openAudit(a.auditPath)
+		if err != nil {
+			return err
+		}
+		defer audit.close()
+		a.audit = audit
+	}
+
+	// Collect the manifest of the outputs
+	if a.manifestPath != "" && !a.dryRun {
+		a.manifest = &manifest{}
+		defer func() { err = a.writeManifest(a.manifestPath, err) }()
+	}
+
+	// Open the archive receiving the outputs
+	if a.archivePath != "" && !a.dryRun {
+		a.archive, err = openArchive(a.archivePath, a.force)
+		if err != nil {
+			return err
+		}
+		defer func() { err = a.archive.finish(err) }()
+	}
+
+	// Run the hook on all generated files, once all are written
+	defer func() {
+		if err == nil {
+			err = a.runHooks()
+		}
+	}()
+
+	// Remove the old outputs, once all are written
+	if a.keepLast > 0 {
+		// The files of a template tree are not named by --out, the pattern would match them all
+		if info, err := os.Stat(a.templatePath); err == nil && info.IsDir() {
+			return errors.New("--keep-last can not prune the outputs of a template tree")
+		}
+	}
+	defer func() {
+		if err == nil {
+			err = a.pruneOutputs()
+		}
+	}()
+
+	// Get the functions to use in the templates
+	funcs, err := a.funcMap()
 	if err != nil {
 		return err
 	}
 
+	// Load the template library
+	if a.templateDir != "" {
+		a.library, err = readLibrary(a.templateDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Link the latest outputs, once all are written
+	if a.latestLink != "" {
+		if a.latestTmpl, err = a.parseName("latest-link", a.latestLink, funcs); err != nil {
+			return fmt.Errorf("parse --latest-link: %w", err)
+		}
+		if info, err := os.Stat(a.templatePath); !a.perRow() && (err != nil || !info.IsDir()) && usesFields(a.latestTmpl) {
+			return errors.New("--latest-link can not use fields in single file mode")
+		}
+		defer func() {
+			if err == nil {
+				err = a.linkLatest()
+			}
+		}()
+	}
+
+	// Render the banner
+	if a.bannerText != "" {
+		bannerTmpl, err := a.parseName("banner", a.bannerText, funcs)
+		if err != nil {
+			return fmt.Errorf("parse banner: %w", err)
+		}
+		var b strings.Builder
+		if err := bannerTmpl.Execute(&b, nil); err != nil {
+			return fmt.Errorf("render banner: %w", err)
+		}
+		a.banner = b.String()
+	}
+
 	// Load the CSV data
 	rows, err := a.loadCSV()
 	if err != nil {
 		return err
 	}
+	a.debug("%d rows and %d columns read from %s\n", len(rows), len(a.headers), a.sourceFile())
+	// Check that the masked columns exist (after --rename and --columns)
+	if a.mask != nil {
+		fields := slices.Clone(a.headers)
+		for key := range a.vars {
+			fields = append(fields, key)
+		}
+		if err := a.mask.check(fields); err != nil {
+			return err
+		}
+	}
+	// Check the templates fields against the CSV headers
+	if a.check {
+		return a.checkTemplates(funcs)
+	}
+	// Keep only the rows matching the filter
+	if a.filter != "" {
+		rows, err = a.filterRows(rows, funcs)
+		if err != nil {
+			return err
+		}
+		a.debug("%d rows kept by the filter\n", len(rows))
+	}
+	// Drop the duplicate rows
+	if a.unique {
+		columns := a.uniqueBy
+		if len(columns) == 0 {
+			columns = a.headers
+		}
+		n := len(rows)
+		rows, err = uniqueRows(rows, columns)
+		if err != nil {
+			return err
+		}
+		a.debug("%d duplicate rows dropped\n", n-len(rows))
+	}
+	// Sort the rows
+	if err := sortRows(rows, a.sortKeys); err != nil {
+		return err
+	}
+	// Keep only the requested range of rows
+	if a.offset > 0 || a.limit > 0 {
+		rows = sliceRows(rows, a.offset, a.limit)
+		a.debug("%d rows kept by --offset and --limit\n", len(rows))
+	}
+	// Mask the sensitive columns
+	if a.mask != nil {
+		a.mask.apply(rows)
+	}
+
+	// Group the rows if needed
+	var groups []group
+	if a.groupBy != "" {
+		if len(rows) > 0 {
+			if _, ok := getField(rows[0], a.groupBy); !ok {
+				return fmt.Errorf("group by unknown column %q", a.groupBy)
+			}
+		}
+		groups = groupRows(rows, a.groupBy)
+		a.debug("%d groups of %s\n", len(groups), a.groupBy)
+		for _, g := range groups {
+			for i, row := range g.Rows {
+				row[a.localCounter] = a.counterValue(i + 1)
+			}
+		}
+	}
+	// Number the rows within their --chunk batch
+	if a.chunk > 0 {
+		for i, row := range rows {
+			row[a.localCounter] = a.counterValue(i%a.chunk + 1)
+		}
+	}
+
+	// Append the totals rows, to each group and, in single file mode, to all the rows
+	info, err := os.Stat(a.templatePath)
+	isTree := err == nil && info.IsDir()
+	if len(a.totals) > 0 {
+		for i := range groups {
+			groups[i].Rows = append(groups[i].Rows, a.totalsRow(groups[i].Rows))
+		}
+		if !isTree && !a.perRow() {
+			rows = append(rows, a.totalsRow(rows))
+		}
+	}
+
+	// Render the whole tree for every row (or group) if the template is a directory
+	if isTree {
+		return a.writeTree(funcs, a.units(rows, groups))
+	}
 
 	// Parse the content template
-	contentTmpl, err := parseTemplate(a.templatePath, funcs)
+	contentTmpl, err := a.parseTemplate(funcs)
 	if err != nil {
 		return err
 	}
 
-	// Create one file per row if output path is a template
-	if strings.Contains(a.outPath, "{{") {
-		nameTmpl, err := template.New("outfile").Funcs(funcs).Parse(a.outPath)
+	// Tile the rows as labels
+	if a.labels != nil {
+		return a.writeLabels(contentTmpl, rows)
+	}
+
+	// Send the rows (or groups) by email
+	if a.mailer != nil {
+		if a.mailer.to, err = a.parseName("mail-to", a.mailTo, funcs); err != nil {
+			return fmt.Errorf("parse --mail-to: %w", err)
+		}
+		if a.mailer.subject, err = a.parseName("mail-subject", a.mailSubject, funcs); err != nil {
+			return fmt.Errorf("parse --mail-subject: %w", err)
+		}
+		return a.sendMails(contentTmpl, a.units(rows, groups))
+	}
+
+	// Create one file per row (or group) if output path is a template
+	if a.perRow() {
+		nameTmpl, err := a.parseName("outfile", a.outPath, funcs)
 		if err != nil {
 			return fmt.Errorf("parse output template: %w", err)
 		}
-		return writePerRow(nameTmpl, contentTmpl, rows, a.force)
+		return a.writePerRow(nameTmpl, contentTmpl, a.units(rows, groups))
 	}
 	// Else create a single file
-	return writeSingle(a.outPath, contentTmpl, rows, a.force)
+	if a.chunk > 0 {
+		return errors.New("--chunk needs an output name template (e.g. out_{{.Number}}.csv)")
+	}
+	if groups != nil {
+		return a.writeSingle(contentTmpl, groups, rows)
+	}
+	return a.writeSingle(contentTmpl, dataset(rows), rows)
+}
+
+// perRow reports whether there is one file per row (or group): if the output path
+// is a template or with --name-by-hash.
+func (a *app) perRow() bool {
+	return a.nameByHash || !a.outLiteral && strings.Contains(a.outPath, a.leftDelim)
 }
 
 // content reads the content from the given file.
 // If the file name is "-", stdin is used.
-// If the file name contains {{...}}, it is treated as a actual content
+// If the file name contains template delimiters ({{...}}), it is treated as a actual content
 // else the file is read and the content is returned.
 // The file encoding is guessed and converted to UTF-8 if needed.
-func content(fileName string) (string, error) {
+func (a *app) content(fileName string) (string, error) {
 	var f io.Reader
+	var size int64
 	if fileName == "-" {
 		// Read from stdin
 		f = os.Stdin
-	} else if strings.Contains(fileName, "{{") && strings.Contains(fileName, "}}") {
+	} else if strings.Contains(fileName, a.leftDelim) && strings.Contains(fileName, a.rightDelim) {
 		// fileName is containing the actual data
 		f = strings.NewReader(fileName)
 	} else {
@@ -266,34 +1370,108 @@ func content(fileName string) (string, error) {
 		} else {
 			defer ff.Close()
 			f = ff
+			if info, err := ff.Stat(); err == nil {
+				size = info.Size()
+			}
+		}
+	}
+	if a.strictUTF8 {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return "", fmt.Errorf("read content: %w", err)
+		}
+		if err := checkUTF8(content); err != nil {
+			return "", err
 		}
+		return strings.TrimPrefix(string(content), "\ufeff"), nil
 	}
-	content, err := io.ReadAll(utf8reader.New(f))
+	content, err := readString(utf8reader.New(f), size)
 	if err != nil {
 		return "", fmt.Errorf("read content: %w", err)
 	}
-	return string(content), nil
+	return content, nil
 }
 
 // loadCSV reads the CSV file and returns a slice of maps representing the rows.
-func (a *app) loadCSV() ([]map[string]string, error) {
+func (a *app) loadCSV() ([]map[string]any, error) {
 	// Open the CSV file
-	csvContent, err := content(a.csvPath)
-	csvContent = skipLines(csvContent, a.keep)
+	fullContent, err := a.input(a.csvPath, 0)
 	if err != nil {
 		return nil, fmt.Errorf("read csv: %w", err)
 	}
+	fullContent = dropLastLines(fullContent, a.skipFooter)
+	csvContent := skipLines(skipLines(fullContent, skipNumber(a.skipRows)), a.keep)
+	skipped := strings.Count(fullContent[:len(fullContent)-len(csvContent)], "\n")
+	comma := a.csvSep
+	if a.csvSepMulti != "" {
+		csvContent, comma, err = translateSeparator(csvContent, a.csvSepMulti, a.comment)
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+	}
 	reader := csv.NewReader(strings.NewReader(csvContent))
-	reader.Comma = a.csvSep
-	// Read all data
-	data, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("read csv: %w", err)
+	reader.Comma = comma
+	reader.Comment = a.comment
+	reader.LazyQuotes = a.lazyQuotes
+	if a.allowRagged {
+		reader.FieldsPerRecord = -1
+	}
+	// Read all data, keeping the line number of every record
+	var data [][]string
+	var lines []int
+	var cleaned, firstCleaned int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", parseError(err, skipped))
+		}
+		line, _ := reader.FieldPos(0)
+		if a.maxFieldSize > 0 {
+			if err := checkFieldSize(record, a.maxFieldSize); err != nil {
+				return nil, fmt.Errorf("read csv: line %d: %w", skipped+line, err)
+			}
+		}
+		if a.stripCells {
+			if n := stripInvisible(record); n > 0 {
+				if cleaned == 0 {
+					firstCleaned = skipped + line
+				}
+				cleaned += n
+			}
+		}
+		if a.trim {
+			trimRecord(record, a.collapse)
+		}
+		data = append(data, record)
+		lines = append(lines, skipped+line)
 	}
 	if len(data) == 0 {
 		return nil, errors.New("csv is empty")
 	}
 
+	// Swap the lines and the columns (the line numbers become column numbers)
+	if a.transpose {
+		data = transposeRecords(data)
+		lines = make([]int, len(data))
+		for i := range lines {
+			lines[i] = i + 1
+		}
+	}
+
+	// Detect whether the first row is a header
+	if a.autoHeader {
+		isHeader, reason := looksLikeHeader(data[0])
+		a.noHeader = !isHeader
+		if isHeader {
+			fmt.Fprintf(os.Stderr, "csvplate: line %d used as header (%s)\n", lines[0], reason)
+		} else {
+			fmt.Fprintf(os.Stderr, "csvplate: line %d used as data, no header (%s)\n", lines[0], reason)
+		}
+	}
+
 	// Determine headers : either from first row or generate C1, C2, ...
 	var headers []string
 	start := 0
@@ -304,152 +1482,539 @@ func (a *app) loadCSV() ([]map[string]string, error) {
 			headers[i] = fmt.Sprintf("C%d", i+1)
 		}
 	} else {
-		headers = data[0]
+		if n := stripInvisible(data[0]); n > 0 {
+			cleaned += n
+			firstCleaned = lines[0]
+		}
+		headers, err = a.dedupeHeaders(data[0])
+		if err != nil {
+			return nil, err
+		}
 		start = 1
 	}
+	if cleaned > 0 {
+		a.warn("removed byte order marks or zero-width characters from %d cells (first at line %d)\n", cleaned, firstCleaned)
+	}
+	if a.pivot != nil {
+		headers, data, lines, err = a.pivot.apply(headers, data[start:], lines[start:])
+		if err != nil {
+			return nil, err
+		}
+		start = 0
+	}
+	renameHeaders(headers, a.renames)
+	// Select and rename the columns (all by default)
+	indexes := make([]int, len(headers))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if len(a.columns) > 0 {
+		indexes, headers, err = selectColumns(headers, a.columns)
+		if err != nil {
+			return nil, fmt.Errorf("select columns: %w", err)
+		}
+	}
+	a.headers = headers
+	if err := a.schema.checkHeaders(headers); err != nil {
+		return nil, fmt.Errorf("check schema: %w", err)
+	}
+	// Fill down the empty cells, before they are parsed
+	if err := fillDown(data[start:], headers, indexes, a.fillDown, a.naValues); err != nil {
+		return nil, err
+	}
 
 	// Build the result slice of maps
-	result := make([]map[string]string, 0, len(data)-start)
+	result := make([]map[string]any, 0, len(data)-start)
+	var numErrors int
 	for c, row := range data[start:] {
 		if len(row) == 0 {
 			continue
 		}
-		entry := make(map[string]string, len(headers))
-		for i, header := range headers {
-			if i < len(row) {
-				entry[header] = row[i]
-			} else {
-				entry[header] = ""
+		entry := make(map[string]any, len(headers))
+		for j, header := range headers {
+			var cell string
+			if i := indexes[j]; i < len(row) {
+				cell = row[i]
+			}
+			if slices.Contains(a.naValues, cell) {
+				if a.inferTypes && a.schema.column(header) == nil {
+					entry[header] = nil
+					continue
+				}
+				cell = ""
 			}
+			col := a.schema.column(header)
+			if col == nil && slices.Contains(a.jsonColumns, header) {
+				col = jsonColumn
+			}
+			if col == nil && slices.Contains(a.binaryCols, header) {
+				col = binaryColumn
+			}
+			if col == nil {
+				entry[header] = a.round.apply(header, a.value(cell))
+				continue
+			}
+			v, err := col.parse(cell)
+			if err != nil {
+				numErrors++
+				fmt.Fprintf(os.Stderr, "  line %d: column %s: %v\n", lines[start+c], header, err)
+				continue
+			}
+			entry[header] = a.round.apply(header, v)
+		}
+		// Add the extra variables
+		for key, value := range a.vars {
+			entry[key] = a.value(value)
 		}
 		// Add the counter field
-		entry[a.counter] = fmt.Sprintf("%d", c+1)
+		entry[a.counter] = a.counterValue(c + 1)
+		// Nest the dotted fields
+		if !a.noNested {
+			nestFields(entry)
+		}
 
 		result = append(result, entry)
 	}
+	if numErrors > 0 {
+		return nil, fmt.Errorf("%d invalid cells", numErrors)
+	}
 	return result, nil
 }
 
-// parseTemplate reads and parses a template file with the given functions.
-func parseTemplate(path string, funcs template.FuncMap) (*template.Template, error) {
+// parseError rewrites a CSV parsing error with the line number in the whole file
+// (the skipped lines count) and the column, and suggests the flag accepting such rows.
+func parseError(err error, skipped int) error {
+	var perr *csv.ParseError
+	if !errors.As(err, &perr) {
+		return err
+	}
+	hint := ""
+	switch {
+	case errors.Is(perr.Err, csv.ErrQuote), errors.Is(perr.Err, csv.ErrBareQuote):
+		hint = " (see --lazy-quotes)"
+	case errors.Is(perr.Err, csv.ErrFieldCount):
+		hint = " (see --allow-ragged)"
+	}
+	return fmt.Errorf("line %d, column %d: %w%s", skipped+perr.Line, perr.Column, perr.Err, hint)
+}
+
+// counterValue returns the value of a counter field for the n-th row (from 1),
+// with the --counter-start, --counter-step and --counter-format:
+// an int with --infer-types and no format, else a string.
+func (a *app) counterValue(n int) any {
+	v := a.counterStart + (n-1)*a.counterStep
+	switch {
+	case a.counterFmt != "":
+		return fmt.Sprintf(a.counterFmt, v)
+	case a.inferTypes:
+		return v
+	default:
+		return strconv.Itoa(v)
+	}
+}
+
+// counterNumber returns the row number (from 1) of a counter value, the inverse of counterValue.
+func (a *app) counterNumber(value any) (int, bool) {
+	var v int
+	if a.counterFmt != "" {
+		if _, err := fmt.Sscanf(fmt.Sprint(value), a.counterFmt, &v); err != nil {
+			return 0, false
+		}
+	} else {
+		var err error
+		if v, err = strconv.Atoi(fmt.Sprint(value)); err != nil {
+			return 0, false
+		}
+	}
+	return (v-a.counterStart)/a.counterStep + 1, true
+}
+
+// value returns the value stored in a row for a cell content.
+func (a *app) value(cell string) any {
+	if a.inferTypes {
+		return inferType(cell)
+	}
+	return cell
+}
+
+// executor is a parsed content template, either from text/template or html/template.
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseTemplate reads and parses the content template with the given functions.
+func (a *app) parseTemplate(funcs template.FuncMap) (executor, error) {
 	// Read the template file
-	tmplContent, err := content(path)
+	tmplContent, err := a.input(a.templatePath, 1)
 	if err != nil {
 		return nil, fmt.Errorf("read template: %w", err)
 	}
-	// Parse the template
-	tmpl, err := template.New("content").Funcs(funcs).Parse(tmplContent)
+	partials, err := a.readPartials()
 	if err != nil {
-		return nil, fmt.Errorf("parse template: %w", err)
-	}
-	return tmpl, nil
-}
-
-// sproutFuncMap creates a template.FuncMap with all sprout functions registered.
-func sproutFuncMap() (template.FuncMap, error) {
-	handler := sprout.New()
-	if err := handler.AddGroups(all.RegistryGroup()); err != nil {
-		return nil, fmt.Errorf("register sprout functions: %w", err)
+		return nil, err
 	}
-	return handler.Build(), nil
+	return a.parseContent(append([]templateText{{"content", tmplContent}}, partials...), a.entry, funcs)
 }
 
 // writer creates a writer for the given file name.
 // If the file name is "-", stdout is used.
 // If force is false and the file exists, an error is returned.
 // All necessary directories are created.
+// With --archive, the file is an entry of the archive.
+// If an encrypter is set, the output is encrypted.
+// The output is re-wrapped (--wrap) or re-indented (--pretty), piped through the --postprocess command, and converted
+// to the --out-encoding and --crlf line endings.
 // The resulting io.WriteCloser is used to write the output.
-func writer(fileName string, force bool) (io.WriteCloser, error) {
+func (a *app) writer(fileName string) (io.WriteCloser, error) {
+	// The byte order mark starts the file, it is not repeated when appending
+	bom := true
+	if a.append && fileName != "-" && a.archive == nil {
+		if info, err := os.Stat(fileName); err == nil && info.Size() > 0 {
+			bom = false
+		}
+	}
+	f, err := a.verbatimWriter(fileName)
+	if err != nil {
+		return nil, err
+	}
+	w, err := a.encode(f, bom)
+	if err != nil {
+		abort(f)
+		return nil, err
+	}
+	return a.postprocess(w, fileName), nil
+}
+
+// verbatimWriter creates a writer for the given file name, like writer, but only
+// encrypting the bytes (not encoded nor post-processed), for the copied files.
+func (a *app) verbatimWriter(fileName string) (io.WriteCloser, error) {
+	f, err := a.rawWriter(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if a.encrypt != nil && !a.dryRun {
+		w, err := a.encrypt(f)
+		if err != nil {
+			abort(f)
+			return nil, err
+		}
+		f = w
+	}
+	return f, nil
+}
+
+// rawWriter creates a writer for the given file name, like writer, but writing
+// the bytes as they are (not encrypted, encoded nor post-processed).
+func (a *app) rawWriter(fileName string) (io.WriteCloser, error) {
+	var f io.WriteCloser
+	var err error
+	if a.archive != nil && fileName != "-" {
+		f, err = a.archive.entry(fileName)
+	} else {
+		f, err = a.openOutput(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.measure(f, fileName), nil
+}
+
+// openOutput opens the (clear) output file for writing.
+func (a *app) openOutput(fileName string) (io.WriteCloser, error) {
 	if fileName == "-" {
 		// Write to stdout
+		if a.dryRun {
+			return discard{}, nil
+		}
 		return os.Stdout, nil
 	}
 	// Create output directories (if needed)
-	outDir := filepath.Dir(fileName)
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return nil, fmt.Errorf("create directories: %w", err)
+	if !a.dryRun {
+		outDir := filepath.Dir(fileName)
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create directories: %w", err)
+		}
+	}
+	// Compare with the existing file when closed
+	if a.ifChanged && !a.dryRun {
+		if _, err := os.Stat(fileName); err == nil {
+			return &changedWriter{name: fileName, force: a.force}, nil
+		}
 	}
 	// Check if file exists
-	if !force {
+	if !a.force && !a.append {
 		if _, statErr := os.Stat(fileName); statErr == nil {
 			return nil, fmt.Errorf("output file %s already exists (use -force to overwrite)", fileName)
 		} else if !os.IsNotExist(statErr) {
 			return nil, fmt.Errorf("inspect output file %s: %w", fileName, statErr)
 		}
 	}
-	// Create the output file
-	f, err := os.Create(fileName)
+	if a.dryRun {
+		return discard{}, nil
+	}
+	// Create the output file (renamed from a temporary file when closed)
+	f, err := createAtomic(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("create output file: %w", err)
 	}
+	if a.append {
+		if err := f.copyFrom(fileName); err != nil {
+			f.Abort()
+			return nil, fmt.Errorf("append to output file: %w", err)
+		}
+	}
 	return f, nil
 }
 
-// writeSingle creates a single output file from the template and all rows.
-func writeSingle(outPath string, tmpl *template.Template, rows []map[string]string, force bool) error {
+// writeSingle creates a single output file from the template and the data (all rows or groups).
+// The rows are the ones contained in the data.
+func (a *app) writeSingle(tmpl executor, data any, rows []map[string]any) error {
+	if a.splitSize > 0 {
+		if a.outPath == "-" {
+			return errors.New("--split-size needs an output file (--out)")
+		}
+		return a.writeSplit(tmpl, data, rows)
+	}
 	// Get the file writer
-	f, err := writer(outPath, force)
+	f, err := a.writer(a.outPath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 	// Render the template
-	if err := tmpl.Execute(f, rows); err != nil {
+	if err := tmpl.Execute(f, data); err != nil {
+		abort(f)
+		a.recordFailure(a.outPath, rows, err)
 		return fmt.Errorf("execute template: %w", err)
 	}
+	if err := f.Close(); err != nil {
+		a.recordFailure(a.outPath, rows, err)
+		return fmt.Errorf("close output: %w", err)
+	}
+	a.recordOutput(a.outPath, rows, f)
+	if err := a.recordAudit(a.outPath, outputSink(a.outPath), rows); err != nil {
+		return err
+	}
+	if err := a.recordLatest(a.outPath, nil); err != nil {
+		return err
+	}
 
-	if outPath != "-" {
-		fmt.Printf("result saved in %s\n", outPath)
+	a.generated(a.outPath, !unchanged(f))
+	if a.outPath != "-" {
+		if a.dryRun {
+			a.info("result would be saved in %s\n", a.outPath)
+		} else if unchanged(f) {
+			a.info("result unchanged in %s\n", a.outPath)
+		} else {
+			a.info("result saved in %s\n", a.outPath)
+		}
 	}
 	return nil
 }
 
-// writePerRow creates one output file per row using the name and content templates.
-func writePerRow(nameTmpl, contentTmpl *template.Template, rows []map[string]string, force bool) error {
+// unit is the data rendered into one output file in per-row mode: a row or a group.
+type unit struct {
+	// name identifies the unit in error messages
+	name string
+	// data is the dot of the name and content templates
+	data any
+	// rows are the rows contained in data
+	rows []map[string]any
+	// nameData is the dot of the name templates, if not data
+	nameData any
+}
+
+// nameDot returns the dot of the name templates of the unit.
+func (u unit) nameDot() any {
+	if u.nameData != nil {
+		return u.nameData
+	}
+	return u.data
+}
+
+// units returns the units rendered one by one: the groups, the --chunk batches of rows, or the rows.
+func (a *app) units(rows []map[string]any, groups []group) []unit {
+	switch {
+	case groups != nil:
+		return groupUnits(groups)
+	case a.chunk > 0:
+		return chunkUnits(rows, a.chunk)
+	default:
+		return rowUnits(rows)
+	}
+}
+
+// rowUnits returns one unit per row.
+// The dot of every unit is the row with its neighborhood (see withNeighbors).
+func rowUnits(rows []map[string]any) []unit {
+	units := make([]unit, len(rows))
+	for idx := range rows {
+		units[idx] = unit{name: fmt.Sprintf("row %d", idx), data: withNeighbors(rows, idx), rows: rows[idx : idx+1]}
+	}
+	return units
+}
+
+// neighborFields are the fields added to the rows in per-row mode by withNeighbors.
+var neighborFields = []string{"Index", "IsFirst", "IsLast", "Prev", "Next", "All"}
+
+// withNeighbors returns a copy of the idx-th row with its position (.Index from 0,
+// .IsFirst, .IsLast), the previous and next rows (.Prev, .Next, nil at the ends)
+// and all rows (.All). The columns of the same names take precedence.
+func withNeighbors(rows []map[string]any, idx int) map[string]any {
+	row := make(map[string]any, len(rows[idx])+len(neighborFields))
+	row["Index"] = idx
+	row["IsFirst"] = idx == 0
+	row["IsLast"] = idx == len(rows)-1
+	row["Prev"], row["Next"] = nil, nil
+	if idx > 0 {
+		row["Prev"] = rows[idx-1]
+	}
+	if idx < len(rows)-1 {
+		row["Next"] = rows[idx+1]
+	}
+	row["All"] = rows
+	for k, v := range rows[idx] {
+		row[k] = v
+	}
+	return row
+}
+
+// chunkUnits returns one unit per batch of size rows. The dot of the content is the
+// rows of the batch, and the dot of the name is the batch as a page (.Number, .Total...).
+func chunkUnits(rows []map[string]any, size int) []unit {
 	if len(rows) == 0 {
 		return nil
 	}
+	pages, _ := paginate(size, dataset(rows))
+	units := make([]unit, len(pages))
+	for idx, p := range pages {
+		units[idx] = unit{name: fmt.Sprintf("chunk %d", p.Number), data: p.Rows, rows: rows[p.First-1 : p.Last], nameData: p}
+	}
+	return units
+}
 
-	fmt.Println("results saved in:")
-	var numErrors int
+// groupUnits returns one unit per group.
+func groupUnits(groups []group) []unit {
+	units := make([]unit, len(groups))
+	for idx, g := range groups {
+		units[idx] = unit{name: fmt.Sprintf("group %q", g.Key), data: g, rows: g.Rows}
+	}
+	return units
+}
+
+// writePerRow creates one output file per unit (row or group) using the name and content templates.
+func (a *app) writePerRow(nameTmpl *template.Template, contentTmpl executor, units []unit) error {
+	if len(units) == 0 {
+		return nil
+	}
+
+	var numErrors, renderErrors int
+	// Generate the output file names
+	var outputs []output
 	var nameBuilder strings.Builder
-	for idx, row := range rows {
-		// Generate the output file name
-		if err := nameTmpl.Execute(&nameBuilder, row); err != nil {
-			return fmt.Errorf("render output name for row %d: %w", idx, err)
+	for _, u := range units {
+		if err := nameTmpl.Execute(&nameBuilder, u.nameDot()); err != nil {
+			nameBuilder.Reset()
+			if err := a.renderFailed(fmt.Errorf("render output name for %s: %w", u.name, err), &renderErrors); err != nil {
+				return err
+			}
+			continue
 		}
 		outName := nameBuilder.String()
 		nameBuilder.Reset()
 		if outName == "" {
-			return fmt.Errorf("rendered output name for row %d is empty", idx)
+			return fmt.Errorf("rendered output name for %s is empty", u.name)
 		}
+		outputs = append(outputs, output{name: outName, units: []unit{u}})
+	}
+	var err error
+	if a.nameByHash {
+		if outputs, err = a.hashOutputs(contentTmpl, outputs, &renderErrors); err != nil {
+			return err
+		}
+	} else if outputs, err = resolveCollisions(outputs, a.onCollision); err != nil {
+		return fmt.Errorf("output name collision: %w", err)
+	}
+
+	if !a.progress {
+		a.info("%s\n", a.savedHeader())
+	}
+
+	for i, o := range outputs {
+		a.showProgress(i, len(outputs))
+		outName := o.name
 		// Get the file writer
-		f, err := writer(outName, force)
+		var rows []map[string]any
+		for _, u := range o.units {
+			rows = append(rows, u.rows...)
+		}
+		var f io.WriteCloser
+		if o.content != nil {
+			f, err = a.rawWriter(outName)
+		} else {
+			f, err = a.writer(outName)
+		}
 		if err != nil {
 			numErrors++
+			a.recordFailure(outName, rows, err)
 			fmt.Fprintf(os.Stderr, "  %s: %v\n", outName, err)
 			continue
-		} else {
-			defer f.Close()
 		}
-		// Render the content template
-		if err := contentTmpl.Execute(f, row); err != nil {
-			return fmt.Errorf("render template for %s: %w", outName, err)
+		// Write the rendered content, or render the content template for all units of the file
+		units := o.units
+		if o.content != nil {
+			if _, err := f.Write(o.content); err != nil {
+				abort(f)
+				a.recordFailure(outName, rows, err)
+				return fmt.Errorf("write %s: %w", outName, err)
+			}
+			units = nil
+		}
+		var failed bool
+		for _, u := range units {
+			if err := contentTmpl.Execute(f, u.data); err != nil {
+				abort(f)
+				a.recordFailure(outName, rows, err)
+				if err := a.renderFailed(fmt.Errorf("render template for %s: %w", outName, err), &renderErrors); err != nil {
+					return err
+				}
+				failed = true
+				break
+			}
+		}
+		if failed {
+			continue
+		}
+		if err := f.Close(); err != nil {
+			a.recordFailure(outName, rows, err)
+			return fmt.Errorf("close %s: %w", outName, err)
+		}
+		a.recordOutput(outName, rows, f)
+		if err := a.recordAudit(outName, outputSink(outName), rows); err != nil {
+			return err
+		}
+		if err := a.recordLatest(outName, o.units[0].nameDot()); err != nil {
+			return err
 		}
-		fmt.Printf("%s\n", outName)
+		a.saved(outName, f)
 	}
+	a.showProgress(len(outputs), len(outputs))
 
-	if numErrors > 0 {
-		return fmt.Errorf("%d files not overwritten.", numErrors)
-	}
-	return nil
+	a.changeSummary()
+	return runErrors(numErrors, renderErrors)
 }
 
 // get the params into new app and run it
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "manifest-diff" {
+		if err := manifestDiff(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "csvplate:", err)
+			os.Exit(exitError)
+		}
+		return
+	}
 	a := newApp()
 	if err := a.run(); err != nil {
 		fmt.Fprintln(os.Stderr, "csvplate:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// runCLI runs csvplate with the arguments, as given on the command line.
+// The messages on stdout are discarded.
+func runCLI(args ...string) error {
+	pflag.CommandLine = pflag.NewFlagSet("csvplate", pflag.ContinueOnError)
+	os.Args = append([]string{"csvplate"}, args...)
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer null.Close()
+	stdout := os.Stdout
+	os.Stdout = null
+	defer func() { os.Stdout = stdout }()
+	return newApp().run()
+}
+
+// writeFile writes a test fixture.
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// renderCSV runs csvplate on the CSV and the template (written in a temporary
+// directory) with the extra arguments, and returns the single output.
+
+func renderCSV(t *testing.T, csv, tmpl string, args ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	in, tpl, out := filepath.Join(dir, "in.csv"), filepath.Join(dir, "in.tmpl"), filepath.Join(dir, "out.txt")
+	writeFile(t, in, csv)
+	writeFile(t, tpl, tmpl)
+	if err := runCLI(append([]string{"-i", in, "-t", tpl, "-o", out}, args...)...); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHTMLEscaping(t *testing.T) {
+	csv := "Name,URL\n<b>Ann</b>,/?a=1&b=2\n"
+	tmpl := `{{range .}}<a href="{{.URL}}">{{.Name}}</a>{{end}}`
+	if got, want := renderCSV(t, csv, tmpl), `<a href="/?a=1&b=2"><b>Ann</b></a>`; got != want {
+		t.Errorf("text/template: got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, tmpl, "--html"), `<a href="/?a=1&amp;b=2">&lt;b&gt;Ann&lt;/b&gt;</a>`; got != want {
+		t.Errorf("html/template: got %q, want %q", got, want)
+	}
+}
+
+func TestDelims(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	out := filepath.Join(dir, "letter_[[.Name]].tex")
+	if err := runCLI("-i", csv, "-t", `\textbf{[[.Name]]} {{not a field}}`, "-o", out, "--delims", "[[,]]"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "letter_Ann.tex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `\textbf{Ann} {{not a field}}`; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
+
+func TestSetFields(t *testing.T) {
+	got := renderCSV(t, "Name,Env\nAnn,dev\n", "{{range .}}{{.Name}} {{.Env}} {{.Date}}{{end}}",
+		"--set", "Env=prod", "--set", "Date=2024-01-31=end")
+	if want := "Ann prod 2024-01-31=end"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDropLastLines(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 1, "a\nb\n"},
+		{"a\r\nb\r\nc", 2, "a\n"},
+		{"a\nb\n\n\n", 1, "a\n"},
+		{"a\nb\n", 5, ""},
+		{"a\nb", 0, "a\nb"},
+	}
+	for _, tt := range tests {
+		if got := dropLastLines(tt.text, tt.n); got != tt.want {
+			t.Errorf("dropLastLines(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSkipRows(t *testing.T) {
+	csv := "Export of 2024-05-01\n\nName\nAnn\nBob\nTotal: 2\n"
+	got := renderCSV(t, csv, "{{range .}}{{.Name}} {{end}}", "--skip-rows", "2", "--skip-footer", "1")
+	if got != "Ann Bob " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	csv := "# exported 2024-05-01\nName,Note\nAnn,#1\n# Bob,left\nEve,ok\n"
+	got := renderCSV(t, csv, "{{range .}}{{.Name}}:{{.Note}} {{end}}", "--comment", "#")
+	if got != "Ann:#1 Eve:ok " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	err := parseError(&csv.ParseError{StartLine: 2, Line: 2, Column: 5, Err: csv.ErrBareQuote}, 3)
+	if want := `line 5, column 5: bare " in non-quoted-field (see --lazy-quotes)`; err.Error() != want {
+		t.Errorf("parseError = %q, want %q", err, want)
+	}
+	if !errors.Is(err, csv.ErrBareQuote) {
+		t.Error("the csv error is not wrapped")
+	}
+	other := errors.New("other")
+	if err := parseError(other, 3); err != other {
+		t.Errorf("parseError(other) = %v", err)
+	}
+}
+
+func TestRaggedRows(t *testing.T) {
+	csv := "Name,Age\nAnn\nBob,41,x\n"
+	if got := renderCSV(t, csv, "{{range .}}{{.Name}}:{{.Age}} {{end}}", "--allow-ragged"); got != "Ann: Bob:41 " {
+		t.Errorf("got %q", got)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeFile(t, in, csv)
+	err := runCLI("-i", in, "-t", "x", "-o", filepath.Join(dir, "out.txt"))
+	if want := "read csv: line 2, column 1: wrong number of fields (see --allow-ragged)"; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+	if got := renderCSV(t, "Name\nA \"B\" C\n", "{{range .}}{{.Name}}{{end}}", "--lazy-quotes"); got != `A "B" C` {
+		t.Errorf("--lazy-quotes: got %q", got)
+	}
+}
+
+func TestNAValues(t *testing.T) {
+	csv := "Name,Age\nAnn,NULL\nBob,-\nEve,41\n"
+	tmpl := "{{range .}}{{.Name}}={{.Age}};{{end}}"
+	if got := renderCSV(t, csv, tmpl, "--na", "NULL,-"); got != "Ann=;Bob=;Eve=41;" {
+		t.Errorf("--na: got %q", got)
+	}
+	// with --infer-types the missing values are nil
+	tmpl = "{{range .}}{{if eq .Age nil}}?{{else}}{{.Age}}{{end}}{{end}}"
+	if got := renderCSV(t, csv, tmpl, "--na", "NULL,-", "--infer-types"); got != "??41" {
+		t.Errorf("--na --infer-types: got %q", got)
+	}
+}
+
+func TestCounterValue(t *testing.T) {
+	tests := []struct {
+		app  app
+		n    int
+		want any
+	}{
+		{app{counterStart: 1, counterStep: 1}, 3, "3"},
+		{app{counterStart: 100, counterStep: 10, inferTypes: true}, 3, 120},
+		{app{counterStart: 5, counterStep: -1}, 2, "4"},
+		{app{counterStart: 1, counterStep: 1, counterFmt: "INV-%04d", inferTypes: true}, 12, "INV-0012"},
+	}
+	for _, tt := range tests {
+		v := tt.app.counterValue(tt.n)
+		if v != tt.want {
+			t.Errorf("%+v: counterValue(%d) = %#v, want %#v", tt.app, tt.n, v, tt.want)
+		}
+		if n, ok := tt.app.counterNumber(v); !ok || n != tt.n {
+			t.Errorf("%+v: counterNumber(%v) = %d, %v, want %d", tt.app, v, n, ok, tt.n)
+		}
+	}
+	got := renderCSV(t, "Name\nAnn\nBob\n", "{{range .}}{{._index_}} {{end}}", "--counter-start", "0", "--counter-step", "5", "--counter-format", "%03d")
+	if got != "000 005 " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWithNeighbors(t *testing.T) {
+	rows := []map[string]any{{"Name": "Ann"}, {"Name": "Bob", "Index": "own"}, {"Name": "Cid"}}
+	first := withNeighbors(rows, 0)
+	if first["IsFirst"] != true || first["IsLast"] != false || first["Prev"] != nil || first["Index"] != 0 {
+		t.Errorf("first row = %v", first)
+	}
+	if next, _ := first["Next"].(map[string]any); next["Name"] != "Bob" {
+		t.Errorf("first .Next = %v, want Bob", first["Next"])
+	}
+	if mid := withNeighbors(rows, 1); mid["Index"] != "own" {
+		t.Errorf("the Index column is overwritten: %v", mid["Index"])
+	}
+	if _, ok := rows[1]["Prev"]; ok {
+		t.Error("the row itself is modified")
+	}
+
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	tmpl := "{{if not .IsFirst}}{{.Prev.Name}}<{{end}}{{.Name}}{{if .IsLast}}.{{end}}/{{len .All}}"
+	if err := runCLI("-i", csv, "-t", tmpl, "-o", filepath.Join(dir, "out", "{{.Name}}.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Ann.txt": "Ann/2", "Bob.txt": "Ann<Bob./2"}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestOutLiteral(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	out := filepath.Join(dir, "{{.Name}}-${x}.txt")
+	if err := runCLI("-i", csv, "-t", "{{range .}}{{.Name}}{{end}}", "-o", out, "--out-literal"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "AnnBob" {
+		t.Errorf("got %q, want %q", data, "AnnBob")
+	}
+}
+
+func TestChunkUnits(t *testing.T) {
+	rows := make([]map[string]any, 7)
+	for i := range rows {
+		rows[i] = map[string]any{"n": i + 1}
+	}
+	units := chunkUnits(rows, 3)
+	if len(units) != 3 {
+		t.Fatalf("chunkUnits: %d units, want 3", len(units))
+	}
+	for i, want := range [][2]int{{1, 3}, {4, 6}, {7, 7}} {
+		u := units[i]
+		p, ok := u.nameDot().(page)
+		if !ok {
+			t.Fatalf("unit %d: name dot %T, want page", i, u.nameDot())
+		}
+		if p.Number != i+1 || p.Total != 3 || p.First != want[0] || p.Last != want[1] || p.Count != 7 {
+			t.Errorf("unit %d: page %+v", i, p)
+		}
+		if len(u.rows) != want[1]-want[0]+1 || u.rows[0]["n"] != want[0] {
+			t.Errorf("unit %d: rows %v", i, u.rows)
+		}
+	}
+	if units := chunkUnits(nil, 3); units != nil {
+		t.Errorf("chunkUnits(nil) = %v, want nil", units)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\nEve\n")
+	if err := runCLI("-i", csv, "-t", "{{range .}}{{.Name}} {{end}}", "-o", filepath.Join(dir, "out", "part{{.Number}}of{{.Total}}.txt"), "--chunk", "2"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"part1of2.txt": "Ann Bob ", "part2of2.txt": "Eve "}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestChunkLocalCounter(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\nEve\n")
+	if err := runCLI("-i", csv, "-t", "{{range .}}{{._index_}}.{{._local_}} {{end}}", "-o", filepath.Join(dir, "out", "part{{.Number}}.txt"), "--chunk", "2"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"part1.txt": "1.1 2.2 ", "part2.txt": "3.1 "}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
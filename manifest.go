@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// The exit codes of csvplate, also recorded in the manifest.
+const (
+	exitOK = 0
+	// exitError is any error stopping the run
+	exitError = 1
+	// exitNotOverwritten is returned when some outputs existed and were not overwritten
+	exitNotOverwritten = 2
+	// exitRenderErrors is returned when some outputs could not be rendered (dry-run)
+	exitRenderErrors = 3
+)
+
+// errNotOverwritten and errRenderErrors are wrapped by the errors of runs
+// where only some of the outputs failed.
+var (
+	errNotOverwritten = errors.New("files not overwritten")
+	errRenderErrors   = errors.New("files could not be rendered")
+)
+
+// exitCode returns the exit code for the result of a run.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errNotOverwritten):
+		return exitNotOverwritten
+	case errors.Is(err, errRenderErrors):
+		return exitRenderErrors
+	default:
+		return exitError
+	}
+}
+
+// manifest records every output of the run, written as JSON with --manifest.
+type manifest struct {
+	ExitCode int             `json:"exitCode"`
+	Error    string          `json:"error,omitempty"`
+	Files    []manifestEntry `json:"files"`
+}
+
+// manifestEntry describes one output: its rows, its size and checksum and whether
+// it was created, overwritten, skipped (unchanged) or failed (error).
+type manifestEntry struct {
+	Output string `json:"output"`
+	Rows   []int  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// measuredWriter counts and hashes the bytes of an output.
+// existed tells whether the output file existed before the run.
+type measuredWriter struct {
+	io.WriteCloser
+	hash    hash.Hash
+	size    int64
+	existed bool
+}
+
+// measure wraps the output file fileName to measure what is written to it, if a manifest is requested.
+func (a *app) measure(f io.WriteCloser, fileName string) io.WriteCloser {
+	if a.manifest == nil {
+		return f
+	}
+	m := &measuredWriter{WriteCloser: f, hash: sha256.New()}
+	if fileName != "-" && a.archive == nil {
+		_, err := os.Stat(fileName)
+		m.existed = err == nil
+	}
+	return m
+}
+
+func (m *measuredWriter) Write(p []byte) (int, error) {
+	n, err := m.WriteCloser.Write(p)
+	m.hash.Write(p[:n])
+	m.size += int64(n)
+	return n, err
+}
+
+// Abort discards the underlying output.
+func (m *measuredWriter) Abort() {
+	abort(m.WriteCloser)
+}
+
+func (m *measuredWriter) underlying() io.WriteCloser { return m.WriteCloser }
+
+// recordOutput adds the closed output w to the manifest, if any.
+func (a *app) recordOutput(output string, rows []map[string]any, w io.WriteCloser) {
+	if a.manifest == nil {
+		return
+	}
+	entry := manifestEntry{Output: output, Rows: a.rowNumbers(rows), Status: "created"}
+	for w != nil {
+		if m, ok := w.(*measuredWriter); ok {
+			entry.Bytes = m.size
+			entry.SHA256 = hex.EncodeToString(m.hash.Sum(nil))
+			if m.existed {
+				entry.Status = "overwritten"
+			}
+		}
+		u, ok := w.(interface{ underlying() io.WriteCloser })
+		if !ok {
+			break
+		}
+		w = u.underlying()
+	}
+	if unchanged(w) {
+		entry.Status = "skipped"
+	}
+	a.manifest.Files = append(a.manifest.Files, entry)
+}
+
+// recordFailure adds the output that failed to the manifest, if any.
+func (a *app) recordFailure(output string, rows []map[string]any, err error) {
+	if a.manifest == nil {
+		return
+	}
+	a.manifest.Files = append(a.manifest.Files, manifestEntry{Output: output, Rows: a.rowNumbers(rows), Status: "error", Error: err.Error()})
+}
+
+// writeManifest writes the manifest file for the result of the run.
+// It returns the error of the run, or else the writing error.
+func (a *app) writeManifest(path string, runErr error) error {
+	a.manifest.ExitCode = exitCode(runErr)
+	if runErr != nil {
+		a.manifest.Error = runErr.Error()
+	}
+	if a.manifest.Files == nil {
+		a.manifest.Files = []manifestEntry{}
+	}
+	data, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("write manifest: %w", err))
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return errors.Join(runErr, fmt.Errorf("write manifest: %w", err))
+	}
+	return runErr
+}
+
+// rowNumbers returns the row numbers of the rows, from their counter values.
+func (a *app) rowNumbers(rows []map[string]any) []int {
+	numbers := make([]int, 0, len(rows))
+	for _, row := range rows {
+		if n, ok := a.counterNumber(row[a.counter]); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
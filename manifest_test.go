@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := map[error]int{
+		nil:                exitOK,
+		errors.New("boom"): exitError,
+		fmt.Errorf("%d %w.", 2, errNotOverwritten): exitNotOverwritten,
+		runErrors(0, 1): exitRenderErrors,
+	}
+	for err, want := range tests {
+		if got := exitCode(err); got != want {
+			t.Errorf("exitCode(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	writeFile(t, filepath.Join(dir, "Bob.txt"), "old")
+	manifestPath := filepath.Join(dir, "manifest.json")
+	err := runCLI("-i", csv, "-t", "Hi {{.Name}}", "-o", filepath.Join(dir, "{{.Name}}.txt"), "--manifest", manifestPath)
+	if exitCode(err) != exitNotOverwritten {
+		t.Fatalf("err = %v", err)
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.ExitCode != exitNotOverwritten || len(m.Files) != 2 {
+		t.Fatalf("manifest = %+v", m)
+	}
+	sum := sha256.Sum256([]byte("Hi Ann"))
+	ann := m.Files[0]
+	if ann.Status != "created" || ann.Bytes != 6 || ann.SHA256 != hex.EncodeToString(sum[:]) || len(ann.Rows) != 1 || ann.Rows[0] != 1 {
+		t.Errorf("Ann entry = %+v", ann)
+	}
+	if bob := m.Files[1]; bob.Status != "error" || bob.Error == "" {
+		t.Errorf("Bob entry = %+v", bob)
+	}
+}
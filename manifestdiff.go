@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// readManifest reads a manifest file written by --manifest.
+func readManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// manifestChanges are the differences between the outputs of two manifests.
+type manifestChanges struct {
+	added, removed, changed, failed []string
+}
+
+// diffManifests compares the outputs of two runs: the outputs only in the new
+// manifest are added, the ones only in the old one are removed, and the ones of
+// both with a different checksum are changed. The outputs that failed in the
+// new run are listed apart, their content being unknown.
+func diffManifests(oldM, newM *manifest) manifestChanges {
+	sums := make(map[string]string, len(oldM.Files))
+	for _, f := range oldM.Files {
+		sums[f.Output] = f.SHA256
+	}
+	var c manifestChanges
+	seen := make(map[string]bool, len(newM.Files))
+	for _, f := range newM.Files {
+		seen[f.Output] = true
+		sum, ok := sums[f.Output]
+		switch {
+		case f.Status == "error":
+			c.failed = append(c.failed, f.Output)
+		case !ok:
+			c.added = append(c.added, f.Output)
+		case sum != f.SHA256:
+			c.changed = append(c.changed, f.Output)
+		}
+	}
+	for _, f := range oldM.Files {
+		if !seen[f.Output] {
+			c.removed = append(c.removed, f.Output)
+		}
+	}
+	for _, list := range [][]string{c.added, c.removed, c.changed, c.failed} {
+		slices.Sort(list)
+	}
+	return c
+}
+
+// print writes one line per difference, prefixed by + (added), - (removed),
+// ~ (changed) or ! (failed), followed by a summary line.
+func (c manifestChanges) print(w io.Writer) {
+	for _, group := range []struct {
+		mark  string
+		names []string
+	}{{"+", c.added}, {"-", c.removed}, {"~", c.changed}, {"!", c.failed}} {
+		for _, name := range group.names {
+			fmt.Fprintf(w, "%s %s\n", group.mark, name)
+		}
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d failed\n", len(c.added), len(c.removed), len(c.changed), len(c.failed))
+}
+
+// manifestDiff runs the manifest-diff subcommand: csvplate manifest-diff old.json new.json.
+func manifestDiff(args []string, w io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("usage: csvplate manifest-diff old.json new.json")
+	}
+	oldM, err := readManifest(args[0])
+	if err != nil {
+		return err
+	}
+	newM, err := readManifest(args[1])
+	if err != nil {
+		return err
+	}
+	diffManifests(oldM, newM).print(w)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestDiff(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out", "{{.Name}}.txt")
+	run := func(csv, manifest string) {
+		t.Helper()
+		in := filepath.Join(dir, "in.csv")
+		writeFile(t, in, csv)
+		if err := runCLI("-i", in, "-t", "{{.Name}} {{.Age}}", "-o", out, "-f", "--manifest", filepath.Join(dir, manifest)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("Name,Age\nAnn,30\nBob,40\nEve,50\n", "old.json")
+	run("Name,Age\nAnn,31\nCid,20\nEve,50\n", "new.json")
+
+	var b strings.Builder
+	if err := manifestDiff([]string{filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")}, &b); err != nil {
+		t.Fatal(err)
+	}
+	name := func(n string) string { return filepath.Join(dir, "out", n+".txt") }
+	want := "+ " + name("Cid") + "\n- " + name("Bob") + "\n~ " + name("Ann") + "\n1 added, 1 removed, 1 changed, 0 failed\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestManifestDiffErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	writeFile(t, bad, "{")
+	for _, args := range [][]string{{bad}, {bad, bad}, {filepath.Join(dir, "missing.json"), bad}} {
+		if err := manifestDiff(args, &strings.Builder{}); err == nil {
+			t.Errorf("manifestDiff(%q): no error", args)
+		}
+	}
+}
+
+func TestDiffManifestsFailed(t *testing.T) {
+	oldM := &manifest{Files: []manifestEntry{{Output: "a", SHA256: "1"}}}
+	newM := &manifest{Files: []manifestEntry{{Output: "a", Status: "error"}}}
+	if c := diffManifests(oldM, newM); len(c.failed) != 1 || len(c.changed) != 0 || len(c.removed) != 0 {
+		t.Errorf("changes = %+v, want a failed", c)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskPolicy lists the sensitive columns and how their values are masked.
+// The hash strategy is an HMAC-SHA256 keyed by --mask-key-env, so that the
+// masked values can not be recovered by hashing guesses.
+// Example policy file:
+//
+//	rules:
+//	  - columns: [Email, Mail]
+//	    strategy: hash
+//	  - columns: [IBAN]
+//	    strategy: partial
+//	    keep: 4
+//	  - columns: [SSN]
+//	    strategy: redact
+type maskPolicy struct {
+	Rules []maskRule `yaml:"rules"`
+	// key is the key of the hash strategy
+	key []byte
+}
+
+// maskRule applies one masking strategy to all listed columns (names or aliases).
+type maskRule struct {
+	Columns  []string `yaml:"columns"`
+	Strategy string   `yaml:"strategy"`
+	// Keep is the number of trailing characters left visible by the partial strategy.
+	Keep int `yaml:"keep"`
+}
+
+// redacted is the replacement value used by the redact strategy.
+const redacted = "[REDACTED]"
+
+// loadMaskPolicy reads and validates the masking policy file.
+func loadMaskPolicy(path string) (*maskPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mask policy: %w", err)
+	}
+	var p maskPolicy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse mask policy: %w", err)
+	}
+	for i, r := range p.Rules {
+		switch r.Strategy {
+		case "redact", "hash":
+		case "partial":
+			if r.Keep <= 0 {
+				p.Rules[i].Keep = 4
+			}
+		default:
+			return nil, fmt.Errorf("mask policy: unknown strategy %q", r.Strategy)
+		}
+		if len(r.Columns) == 0 {
+			return nil, fmt.Errorf("mask policy: rule %d has no columns", i+1)
+		}
+	}
+	return &p, nil
+}
+
+// hashes reports whether a rule of the policy uses the hash strategy (and needs a key).
+func (p *maskPolicy) hashes() bool {
+	return slices.ContainsFunc(p.Rules, func(r maskRule) bool { return r.Strategy == "hash" })
+}
+
+// check returns an error for the rules none of whose columns is one of the fields,
+// e.g. a column renamed by --rename or --columns, which would be left unmasked.
+func (p *maskPolicy) check(fields []string) error {
+	for i, r := range p.Rules {
+		if !slices.ContainsFunc(r.Columns, func(col string) bool { return slices.Contains(fields, col) }) {
+			return fmt.Errorf("mask policy: rule %d: no column %s (renamed or not selected?)", i+1, strings.Join(r.Columns, ", "))
+		}
+	}
+	return nil
+}
+
+// apply masks, in place, the values of all columns covered by the policy.
+func (p *maskPolicy) apply(rows []map[string]any) {
+	for _, r := range p.Rules {
+		for _, col := range r.Columns {
+			for _, row := range rows {
+				if v, ok := getField(row, col); ok {
+					setField(row, col, r.mask(fmt.Sprint(v), p.key))
+				}
+			}
+		}
+	}
+}
+
+// mask returns the masked version of a single value, the key being used by the hash strategy.
+func (r maskRule) mask(v string, key []byte) string {
+	if v == "" {
+		return v
+	}
+	switch r.Strategy {
+	case "hash":
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(v))
+		return hex.EncodeToString(mac.Sum(nil)[:8])
+	case "partial":
+		runes := []rune(v)
+		if len(runes) <= r.Keep {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-r.Keep) + string(runes[len(runes)-r.Keep:])
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMaskPolicy(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	writeFile(t, good, "rules:\n  - columns: [IBAN]\n    strategy: partial\n  - columns: [SSN, NIR]\n    strategy: redact\n")
+	p, err := loadMaskPolicy(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Rules) != 2 || p.Rules[0].Keep != 4 {
+		t.Errorf("rules = %+v, want 2 rules and keep 4 by default", p.Rules)
+	}
+	for name, policy := range map[string]string{
+		"strategy.yaml": "rules:\n  - columns: [SSN]\n    strategy: shuffle\n",
+		"columns.yaml":  "rules:\n  - strategy: redact\n",
+		"syntax.yaml":   "rules: [",
+	} {
+		path := filepath.Join(dir, name)
+		writeFile(t, path, policy)
+		if _, err := loadMaskPolicy(path); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestMaskPolicyRender(t *testing.T) {
+	policy := filepath.Join(t.TempDir(), "policy.yaml")
+	writeFile(t, policy, "rules:\n  - columns: [IBAN]\n    strategy: partial\n    keep: 2\n  - columns: [SSN]\n    strategy: redact\n")
+	csv := "Name,IBAN,SSN\nAnn,FR761234,123-45-6789\n"
+	tmpl := "{{range .}}{{.Name}} {{.IBAN}} {{.SSN}}{{end}}"
+	if got, want := renderCSV(t, csv, tmpl, "--mask-policy", policy), "Ann ******34 [REDACTED]"; got != want {
+		t.Errorf("masked: got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, tmpl, "--mask-policy", policy, "--unmasked"), "Ann FR761234 123-45-6789"; got != want {
+		t.Errorf("unmasked: got %q, want %q", got, want)
+	}
+
+	writeFile(t, policy, "rules:\n  - columns: [Email]\n    strategy: hash\n")
+	t.Setenv("CSVPLATE_MASK_KEY", "key")
+	got := renderCSV(t, "Email\na@b.c\n", "{{range .}}{{.Email}}{{end}}", "--mask-policy", policy, "--mask-key-env", "CSVPLATE_MASK_KEY")
+	if want := "39ae49c50426b2bd"; got != want {
+		t.Errorf("hashed: got %q, want %q", got, want)
+	}
+}
+
+func TestMaskPolicyCheck(t *testing.T) {
+	p := &maskPolicy{Rules: []maskRule{
+		{Columns: []string{"Email", "Mail"}, Strategy: "hash"},
+		{Columns: []string{"IBAN"}, Strategy: "partial", Keep: 4},
+	}}
+	tests := []struct {
+		name    string
+		fields  []string
+		wantErr bool
+	}{
+		{"all present", []string{"Name", "Email", "IBAN"}, false},
+		{"alias present", []string{"Mail", "IBAN"}, false},
+		{"renamed", []string{"Name", "Courriel", "IBAN"}, true},
+		{"not selected", []string{"Email"}, true},
+	}
+	for _, tt := range tests {
+		if err := p.check(tt.fields); (err != nil) != tt.wantErr {
+			t.Errorf("%s: check(%v) error = %v, want error %v", tt.name, tt.fields, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMaskRule(t *testing.T) {
+	tests := []struct {
+		rule maskRule
+		in   string
+		want string
+	}{
+		{maskRule{Strategy: "redact"}, "secret", redacted},
+		{maskRule{Strategy: "partial", Keep: 4}, "FR7612345678", "********5678"},
+		{maskRule{Strategy: "partial", Keep: 4}, "abc", "***"},
+		{maskRule{Strategy: "hash"}, "a@b.c", "39ae49c50426b2bd"},
+		{maskRule{Strategy: "redact"}, "", ""},
+	}
+	for _, tt := range tests {
+		got := tt.rule.mask(tt.in, []byte("key"))
+		if got != tt.want {
+			t.Errorf("%s mask(%q) = %q, want %q", tt.rule.Strategy, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskHashKey(t *testing.T) {
+	r := maskRule{Strategy: "hash"}
+	if r.mask("a@b.c", []byte("k1")) == r.mask("a@b.c", []byte("k2")) {
+		t.Error("the hash does not depend on the key")
+	}
+	p := &maskPolicy{Rules: []maskRule{{Columns: []string{"SSN"}, Strategy: "redact"}}}
+	if p.hashes() {
+		t.Error("hashes() = true without hash rule")
+	}
+	p.Rules = append(p.Rules, r)
+	if !p.hashes() {
+		t.Error("hashes() = false with a hash rule")
+	}
+}
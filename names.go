@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// salutations are the letter greetings by language, for a man, a woman
+// and another or unknown gender; %s is the last name.
+var salutations = map[string][3]string{
+	"en": {"Dear Mr. %s", "Dear Ms. %s", "Dear Mx. %s"},
+	"fr": {"Cher Monsieur %s", "Chère Madame %s", "Madame, Monsieur"},
+	"de": {"Sehr geehrter Herr %s", "Sehr geehrte Frau %s", "Sehr geehrte Damen und Herren"},
+	"es": {"Estimado Sr. %s", "Estimada Sra. %s", "Estimado/a %s"},
+	"it": {"Gentile Sig. %s", "Gentile Sig.ra %s", "Gentile %s"},
+}
+
+// lastNameFirst are the languages writing the family name first.
+var lastNameFirst = map[string]bool{"hu": true, "ja": true, "ko": true, "zh": true, "vi": true}
+
+// nameParticles are the particles kept in lower case inside a name (Ludwig van Beethoven).
+var nameParticles = map[string]bool{
+	"de": true, "del": true, "della": true, "der": true, "di": true, "du": true, "da": true,
+	"la": true, "le": true, "van": true, "von": true, "den": true, "ten": true, "ter": true,
+}
+
+// genderIndex returns the salutations index of a gender value:
+// 0 for m, male, man, mr; 1 for f, female, woman, ms, mrs; else 2.
+func genderIndex(gender string) int {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "m", "male", "man", "mr", "h", "homme":
+		return 0
+	case "f", "female", "woman", "ms", "mrs", "w", "femme":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// language returns the language part of a locale: fr_CA and fr-CA give fr.
+func language(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(locale)), "_")
+	lang, _, _ = strings.Cut(lang, "-")
+	return lang
+}
+
+// salutation returns the greeting of a letter in the language of the locale
+// for the gender (m, f or other) and the last name: salutation "en" "f" "Smith" gives Dear Ms. Smith.
+func salutation(locale, gender string, lastName any) (string, error) {
+	lang := language(locale)
+	forms, ok := salutations[lang]
+	if !ok {
+		return "", fmt.Errorf("salutation: unsupported language %q", locale)
+	}
+	form := forms[genderIndex(gender)]
+	if !strings.Contains(form, "%s") {
+		return form, nil
+	}
+	return fmt.Sprintf(form, nameCase(fmt.Sprint(lastName))), nil
+}
+
+// nameCase capitalizes every part of a name (after spaces, hyphens and apostrophes)
+// except the inner particles: "JEAN-LUC o'brien DE la fontaine" gives Jean-Luc O'Brien de la Fontaine.
+func nameCase(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	for i, w := range words {
+		if i > 0 && i < len(words)-1 && nameParticles[w] {
+			continue
+		}
+		runes := []rune(w)
+		upper := true
+		for j, r := range runes {
+			if upper {
+				runes[j] = unicode.ToUpper(r)
+			}
+			upper = r == '-' || r == '\'' || r == '’'
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// fullName joins the first and last names in the order of the locale
+// (family name first in Hungarian, Japanese, Korean, Chinese and Vietnamese).
+func fullName(locale string, first, last any) string {
+	f, l := strings.TrimSpace(fmt.Sprint(first)), strings.TrimSpace(fmt.Sprint(last))
+	if lastNameFirst[language(locale)] {
+		f, l = l, f
+	}
+	return strings.TrimSpace(f + " " + l)
+}
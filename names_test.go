@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_nameCase() {
+	fmt.Println(nameCase("JEAN-LUC o'brien DE la fontaine"))
+	fmt.Println(nameCase("van gogh"))
+	// Output:
+	// Jean-Luc O'Brien de la Fontaine
+	// Van Gogh
+}
+
+func TestSalutation(t *testing.T) {
+	tests := []struct {
+		locale, gender, last, want string
+	}{
+		{"en_US", "F", "SMITH", "Dear Ms. Smith"},
+		{"fr-CA", "homme", "dupont", "Cher Monsieur Dupont"},
+		{"de", "", "Müller", "Sehr geehrte Damen und Herren"},
+	}
+	for _, tt := range tests {
+		if got, err := salutation(tt.locale, tt.gender, tt.last); err != nil || got != tt.want {
+			t.Errorf("salutation(%q, %q, %q) = %q, %v, want %q", tt.locale, tt.gender, tt.last, got, err, tt.want)
+		}
+	}
+	if _, err := salutation("nl", "m", "Jansen"); err == nil {
+		t.Error("unsupported language: no error")
+	}
+}
+
+func TestFullName(t *testing.T) {
+	if got := fullName("ja_JP", "Haruki", "Murakami"); got != "Murakami Haruki" {
+		t.Errorf("ja: %q", got)
+	}
+	if got := fullName("en", "Ann", " "); got != "Ann" {
+		t.Errorf("en: %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// nestFields moves, in place, the fields with dotted names into nested maps:
+// the field "address.city" becomes the field "city" of the map in the field "address".
+// A dotted field conflicting with a field that is not a map is left unchanged.
+func nestFields(row map[string]any) {
+	var dotted []string
+	for key := range row {
+		if strings.Contains(key, ".") {
+			dotted = append(dotted, key)
+		}
+	}
+	slices.Sort(dotted)
+	for _, key := range dotted {
+		parts := strings.Split(key, ".")
+		if slices.Contains(parts, "") {
+			continue
+		}
+		if m := subMap(row, parts[:len(parts)-1]); m != nil {
+			m[parts[len(parts)-1]] = row[key]
+			delete(row, key)
+		}
+	}
+}
+
+// subMap returns the nested map at the given path, creating the missing levels.
+// It returns nil if a level exists but is not a map.
+func subMap(m map[string]any, path []string) map[string]any {
+	for _, part := range path {
+		next, ok := m[part]
+		if !ok {
+			nm := make(map[string]any)
+			m[part] = nm
+			m = nm
+			continue
+		}
+		if m, ok = next.(map[string]any); !ok {
+			return nil
+		}
+	}
+	return m
+}
+
+// getField returns the value of a field, following dotted names into nested maps.
+func getField(row map[string]any, name string) (any, bool) {
+	if v, ok := row[name]; ok {
+		return v, true
+	}
+	parts := strings.Split(name, ".")
+	var v any = row
+	for _, part := range parts {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// setField sets the value of an existing field, following dotted names into nested maps.
+func setField(row map[string]any, name string, value any) {
+	if _, ok := row[name]; ok {
+		row[name] = value
+		return
+	}
+	parts := strings.Split(name, ".")
+	var m map[string]any = row
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			return
+		}
+		m = next
+	}
+	if _, ok := m[parts[len(parts)-1]]; ok {
+		m[parts[len(parts)-1]] = value
+	}
+}
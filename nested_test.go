@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNestFields(t *testing.T) {
+	row := map[string]any{
+		"name":         "Ann",
+		"address.city": "Paris",
+		"address.zip":  "75001",
+		"a.b.c":        1,
+		"name.first":   "A", // conflicts with the name string
+		"bad..key":     2,
+	}
+	nestFields(row)
+	want := map[string]any{
+		"name":       "Ann",
+		"address":    map[string]any{"city": "Paris", "zip": "75001"},
+		"a":          map[string]any{"b": map[string]any{"c": 1}},
+		"name.first": "A",
+		"bad..key":   2,
+	}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("nestFields = %v, want %v", row, want)
+	}
+
+	if v, ok := getField(row, "address.city"); !ok || v != "Paris" {
+		t.Errorf("getField(address.city) = %v, %v", v, ok)
+	}
+	if v, ok := getField(row, "name.first"); !ok || v != "A" {
+		t.Errorf("getField(name.first) = %v, %v", v, ok)
+	}
+	if _, ok := getField(row, "address.country"); ok {
+		t.Error("getField(address.country) found")
+	}
+	setField(row, "a.b.c", 2)
+	setField(row, "address.country", "FR") // not an existing field
+	if v, _ := getField(row, "a.b.c"); v != 2 {
+		t.Errorf("setField(a.b.c): %v", v)
+	}
+	if _, ok := getField(row, "address.country"); ok {
+		t.Error("setField created address.country")
+	}
+}
+
+func TestNestedRender(t *testing.T) {
+	csv := "name,address.city\nAnn,Paris\n"
+	tmpl := `{{range .}}{{.address.city}}{{end}}`
+	if got := renderCSV(t, csv, tmpl); got != "Paris" {
+		t.Errorf("nested: got %q, want Paris", got)
+	}
+	tmpl = `{{range .}}{{index . "address.city"}}{{end}}`
+	if got := renderCSV(t, csv, tmpl, "--no-nested"); got != "Paris" {
+		t.Errorf("--no-nested: got %q, want Paris", got)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// outputEncoding returns the encoding named by --out-encoding (e.g. latin1, windows-1252).
+func outputEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(strings.ToLower(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+// encodedWriter converts the output (line endings and encoding) before writing it
+// to dst. Close flushes the conversion and closes dst.
+type encodedWriter struct {
+	io.Writer
+	flush func() error
+	dst   io.WriteCloser
+}
+
+// Close flushes the converted output and closes the underlying writer.
+func (w *encodedWriter) Close() error {
+	if err := w.flush(); err != nil {
+		abort(w.dst)
+		return fmt.Errorf("encode output: %w", err)
+	}
+	return w.dst.Close()
+}
+
+// Abort discards the underlying output.
+func (w *encodedWriter) Abort() {
+	abort(w.dst)
+}
+
+func (w *encodedWriter) underlying() io.WriteCloser { return w.dst }
+
+// utf8BOM is the byte order mark written at the start of the outputs with --bom.
+const utf8BOM = "\ufeff"
+
+// encode wraps dst to apply --crlf and --out-encoding, if set.
+// With --bom, the byte order mark is written first, unless bom is false.
+func (a *app) encode(dst io.WriteCloser, bom bool) (io.WriteCloser, error) {
+	if a.bom && bom {
+		if _, err := io.WriteString(dst, utf8BOM); err != nil {
+			return nil, fmt.Errorf("write byte order mark: %w", err)
+		}
+	}
+	if a.outEncoding == nil && !a.crlf {
+		return dst, nil
+	}
+	w := &encodedWriter{Writer: dst, flush: func() error { return nil }, dst: dst}
+	if a.outEncoding != nil {
+		tw := transform.NewWriter(dst, a.outEncoding.NewEncoder())
+		w.Writer, w.flush = tw, tw.Close
+	}
+	if a.crlf {
+		w.Writer = &crlfWriter{w: w.Writer}
+	}
+	return w, nil
+}
+
+// crlfWriter converts the LF line endings to CRLF (existing CRLF are kept).
+type crlfWriter struct {
+	w io.Writer
+	// cr is true if the last written byte is a CR
+	cr bool
+}
+
+func (c *crlfWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(p) + bytes.Count(p, []byte("\n")))
+	for _, b := range p {
+		if b == '\n' && !c.cr {
+			buf.WriteByte('\r')
+		}
+		buf.WriteByte(b)
+		c.cr = b == '\r'
+	}
+	if _, err := c.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
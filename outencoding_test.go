@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputEncoding(t *testing.T) {
+	for _, name := range []string{"latin1", "Windows-1252", "utf-8"} {
+		if _, err := outputEncoding(name); err != nil {
+			t.Errorf("outputEncoding(%q): %v", name, err)
+		}
+	}
+	if _, err := outputEncoding("klingon"); err == nil {
+		t.Error("unknown encoding: no error")
+	}
+}
+
+func TestCRLFWriter(t *testing.T) {
+	var b strings.Builder
+	w := &crlfWriter{w: &b}
+	// a CRLF split between two writes is kept as is
+	for _, s := range []string{"a\nb\r", "\nc\r\n", "\n"} {
+		w.Write([]byte(s))
+	}
+	if want := "a\r\nb\r\nc\r\n\r\n"; b.String() != want {
+		t.Errorf("crlfWriter = %q, want %q", b.String(), want)
+	}
+}
+
+func TestOutEncodingRender(t *testing.T) {
+	got := renderCSV(t, "Name\nRené\n", "{{range .}}{{.Name}}\n{{end}}", "--out-encoding", "latin1", "--crlf")
+	if want := "Ren\xe9\r\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBOM(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	out := filepath.Join(dir, "out.csv")
+	for range 2 {
+		if err := runCLI("-i", csv, "-t", "{{range .}}{{.Name}}\n{{end}}", "-o", out, "--bom", "--append"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the byte order mark is not repeated when appending
+	if data, _ := os.ReadFile(out); string(data) != "\ufeffAnn\nAnn\n" {
+		t.Errorf("out.csv = %q", data)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// countLines returns the number of lines of the text (a final newline does not start a line).
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+}
+
+// estimateLines returns the number of lines of the text once its lines are
+// wrapped at width characters (the words are not split).
+func estimateLines(width int, text string) int {
+	if width <= 0 {
+		return countLines(text)
+	}
+	var n int
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		lines, length := 1, 0
+		for _, word := range strings.Fields(line) {
+			w := utf8.RuneCountInString(word)
+			if length > 0 && length+1+w > width {
+				lines++
+				length = 0
+			}
+			if length > 0 {
+				length++
+			}
+			length += w
+		}
+		n += lines
+	}
+	if text == "" {
+		return 0
+	}
+	return n
+}
+
+// pageBreak returns the page break for the output format: html, latex or text (form feed).
+func pageBreak(format string) (string, error) {
+	switch format {
+	case "html":
+		return `<div style="break-after: page"></div>`, nil
+	case "latex", "tex":
+		return `\newpage`, nil
+	case "text", "":
+		return "\f", nil
+	default:
+		return "", fmt.Errorf("pageBreak: unknown format %q (expected html, latex or text)", format)
+	}
+}
+
+// pager keeps track of the lines used on the current page, to avoid splitting sections:
+//
+//	{{$p := pager 60}}{{range .}}{{if $p.Need (estimateLines 80 .Text)}}{{pageBreak "text"}}{{end}}{{.Text}}{{end}}
+type pager struct {
+	// Lines is the number of lines of a page
+	Lines int
+	// Used is the number of lines used on the current page
+	Used int
+	// Page is the current page number, starting at 1
+	Page int
+}
+
+// newPager returns a pager for pages of the given number of lines.
+func newPager(lines int) (*pager, error) {
+	if lines <= 0 {
+		return nil, fmt.Errorf("pager: the page must have lines, got %d", lines)
+	}
+	return &pager{Lines: lines, Page: 1}, nil
+}
+
+// Need reserves n lines for a section and tells whether a page break is needed before it,
+// that is if the section does not fit in the rest of the current (not empty) page.
+func (p *pager) Need(n int) bool {
+	if p.Used > 0 && p.Used+n > p.Lines {
+		p.Page++
+		p.Used = n % p.Lines
+		return true
+	}
+	p.Used += n
+	return false
+}
+
+// Break records a forced page break (it returns an empty string to be used in actions).
+func (p *pager) Break() string {
+	p.Page++
+	p.Used = 0
+	return ""
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_estimateLines() {
+	fmt.Println(countLines("a\nb\n"), countLines("a\nb"), countLines(""))
+	fmt.Println(estimateLines(10, "one two three four\nfive\n"), estimateLines(0, "a\nb"))
+	// Output:
+	// 2 2 0
+	// 3 2
+}
+
+func TestPager(t *testing.T) {
+	p, err := newPager(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var breaks []bool
+	for _, n := range []int{4, 5, 3, 12, 1} {
+		breaks = append(breaks, p.Need(n))
+	}
+	if fmt.Sprint(breaks) != "[false false true true false]" || p.Page != 3 || p.Used != 3 {
+		t.Errorf("breaks %v, page %d, used %d", breaks, p.Page, p.Used)
+	}
+	p.Break()
+	if p.Page != 4 || p.Used != 0 || p.Need(10) {
+		t.Errorf("after Break: %+v", p)
+	}
+	if _, err := newPager(0); err == nil {
+		t.Error("pager 0: no error")
+	}
+}
+
+func TestPageBreak(t *testing.T) {
+	for format, want := range map[string]string{"": "\f", "tex": `\newpage`, "html": `<div style="break-after: page"></div>`} {
+		if got, err := pageBreak(format); err != nil || got != want {
+			t.Errorf("pageBreak(%q) = %q, %v", format, got, err)
+		}
+	}
+	if _, err := pageBreak("rtf"); err == nil {
+		t.Error("rtf: no error")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "fmt"
+
+// page is a page of rows returned by the paginate template function.
+type page struct {
+	// Number is the page number, starting at 1
+	Number int
+	// Total is the number of pages
+	Total int
+	// Rows are the rows (or any items) of the page
+	Rows any
+	// First and Last are the positions, starting at 1, of the first and last rows of the page
+	First, Last int
+	// Count is the number of rows of all pages
+	Count int
+}
+
+// IsFirst tells whether p is the first page.
+func (p page) IsFirst() bool { return p.Number == 1 }
+
+// IsLast tells whether p is the last page.
+func (p page) IsLast() bool { return p.Number == p.Total }
+
+// paginate splits the rows (or any slice) in pages of size rows:
+//
+//	{{range paginate 20 .}}Page {{.Number}}/{{.Total}}: rows {{.First}}-{{.Last}} of {{.Count}}
+//	{{range .Rows}}...{{end}}{{end}}
+//
+// An empty slice gives a single empty page.
+func paginate(size int, rows any) ([]page, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("paginate: page size must be positive, got %d", size)
+	}
+	v, err := sliceValue("paginate", rows)
+	if err != nil {
+		return nil, err
+	}
+	count := v.Len()
+	total := max(1, (count+size-1)/size)
+	pages := make([]page, total)
+	for n := range pages {
+		i, j := n*size, min(count, (n+1)*size)
+		pages[n] = page{Number: n + 1, Total: total, Rows: v.Slice(i, j).Interface(), First: min(i+1, j), Last: j, Count: count}
+	}
+	return pages, nil
+}
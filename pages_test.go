@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_paginate() {
+	pages, _ := paginate(2, []string{"a", "b", "c"})
+	for _, p := range pages {
+		fmt.Println(p.Number, p.Total, p.Rows, p.First, p.Last, p.Count, p.IsFirst(), p.IsLast())
+	}
+	// Output:
+	// 1 2 [a b] 1 2 3 true false
+	// 2 2 [c] 3 3 3 false true
+}
+
+func TestPaginate(t *testing.T) {
+	pages, err := paginate(10, []int{})
+	if err != nil || len(pages) != 1 || pages[0].First != 0 || pages[0].Last != 0 {
+		t.Errorf("paginate of no rows = %+v, %v", pages, err)
+	}
+	if _, err := paginate(0, []int{1}); err == nil {
+		t.Error("page size 0: no error")
+	}
+	if _, err := paginate(2, "abc"); err == nil {
+		t.Error("paginate of a string: no error")
+	}
+	got := renderCSV(t, "N\n1\n2\n3\n", "{{range paginate 2 .}}[{{range .Rows}}{{.N}}{{end}}]{{end}}")
+	if got != "[12][3]" {
+		t.Errorf("got %q", got)
+	}
+}
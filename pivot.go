@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// transposeRecords swaps the lines and the columns of the CSV records
+// (the first column becomes the header line). Missing cells are empty.
+func transposeRecords(data [][]string) [][]string {
+	width := 0
+	for _, record := range data {
+		width = max(width, len(record))
+	}
+	result := make([][]string, width)
+	for j := range result {
+		result[j] = make([]string, len(data))
+		for i, record := range data {
+			if j < len(record) {
+				result[j][i] = record[j]
+			}
+		}
+	}
+	return result
+}
+
+// pivot is a --pivot specification: the columns from the column from onward
+// are turned into rows, with the column name in key and the cell in value.
+type pivot struct {
+	key   string
+	value string
+	from  string
+}
+
+// parsePivot parses a --pivot value of the form key=Month,value=Amount[,from=Jan].
+func parsePivot(spec string) (*pivot, error) {
+	var p pivot
+	for _, part := range strings.Split(spec, ",") {
+		name, v, ok := strings.Cut(part, "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("invalid pivot option %q (expected name=value)", part)
+		}
+		switch strings.TrimSpace(name) {
+		case "key":
+			p.key = v
+		case "value":
+			p.value = v
+		case "from":
+			p.from = v
+		default:
+			return nil, fmt.Errorf("unknown pivot option %q", name)
+		}
+	}
+	if p.key == "" || p.value == "" {
+		return nil, fmt.Errorf("pivot %q needs key= and value=", spec)
+	}
+	return &p, nil
+}
+
+// apply turns every record into one record per pivoted column, made of the
+// leading (kept) cells, the pivoted column name and its cell.
+// The pivoted columns start at the from column, or at the second column by default.
+// The line numbers are repeated for the records coming from the same line.
+func (p *pivot) apply(headers []string, data [][]string, lines []int) ([]string, [][]string, []int, error) {
+	first := min(1, len(headers))
+	if p.from != "" {
+		first = slices.Index(headers, p.from)
+		if first < 0 {
+			return nil, nil, nil, fmt.Errorf("pivot from unknown column %q", p.from)
+		}
+	}
+	kept := headers[:first:first]
+	result := make([][]string, 0, len(data)*(len(headers)-first))
+	var resultLines []int
+	for i, record := range data {
+		if len(record) == 0 {
+			continue
+		}
+		ids := make([]string, first)
+		copy(ids, record)
+		for j := first; j < len(headers); j++ {
+			cell := ""
+			if j < len(record) {
+				cell = record[j]
+			}
+			result = append(result, append(slices.Clone(ids), headers[j], cell))
+			resultLines = append(resultLines, lines[i])
+		}
+	}
+	return append(kept, p.key, p.value), result, resultLines, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransposeRecords(t *testing.T) {
+	got := transposeRecords([][]string{{"Name", "A", "B"}, {"Age", "1"}})
+	want := [][]string{{"Name", "Age"}, {"A", "1"}, {"B", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transposeRecords = %q, want %q", got, want)
+	}
+}
+
+func TestParsePivot(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    *pivot
+		wantErr bool
+	}{
+		{"key=Month,value=Amount", &pivot{key: "Month", value: "Amount"}, false},
+		{"key=Month,value=Amount,from=Jan", &pivot{key: "Month", value: "Amount", from: "Jan"}, false},
+		{"key=Month", nil, true},
+		{"key=Month,value=", nil, true},
+		{"key=Month,value=Amount,to=Dec", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePivot(tt.spec)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePivot(%q) = %v, %v, want %v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPivotApply(t *testing.T) {
+	headers := []string{"Region", "Shop", "Jan", "Feb"}
+	data := [][]string{{"N", "s1", "10", "20"}, {}, {"S", "s2", "30"}}
+	lines := []int{2, 3, 4}
+
+	p := &pivot{key: "Month", value: "Amount", from: "Jan"}
+	gotHeaders, gotData, gotLines, err := p.apply(headers, data, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Region", "Shop", "Month", "Amount"}; !reflect.DeepEqual(gotHeaders, want) {
+		t.Errorf("headers = %q, want %q", gotHeaders, want)
+	}
+	wantData := [][]string{
+		{"N", "s1", "Jan", "10"}, {"N", "s1", "Feb", "20"},
+		{"S", "s2", "Jan", "30"}, {"S", "s2", "Feb", ""},
+	}
+	if !reflect.DeepEqual(gotData, wantData) {
+		t.Errorf("data = %q, want %q", gotData, wantData)
+	}
+	if want := []int{2, 2, 4, 4}; !reflect.DeepEqual(gotLines, want) {
+		t.Errorf("lines = %v, want %v", gotLines, want)
+	}
+	// the headers are not modified
+	if headers[2] != "Jan" {
+		t.Errorf("headers modified: %q", headers)
+	}
+
+	// by default, all the columns after the first one are pivoted
+	p = &pivot{key: "K", value: "V"}
+	gotHeaders, gotData, _, err = p.apply(headers, data[:1], lines[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Region", "K", "V"}; !reflect.DeepEqual(gotHeaders, want) || len(gotData) != 3 {
+		t.Errorf("default pivot = %q, %q", gotHeaders, gotData)
+	}
+
+	p = &pivot{key: "K", value: "V", from: "Mar"}
+	if _, _, _, err := p.apply(headers, data, lines); err == nil {
+		t.Error("pivot from an unknown column: no error")
+	}
+}
+
+func TestPivotRender(t *testing.T) {
+	csv := "Shop,Jan,Feb\ns1,10,20\n"
+	if got, want := renderCSV(t, csv, "{{range .}}{{.Month}}={{.Amount}} {{end}}", "--pivot", "key=Month,value=Amount"), "Jan=10 Feb=20 "; got != want {
+		t.Errorf("--pivot: got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, "{{range .}}{{.Shop}} {{end}}", "--transpose"), "Jan Feb "; got != want {
+		t.Errorf("--transpose: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// postWriter keeps the rendered output in memory and, when closed, transforms it
+// with process, which writes the result to dst.
+type postWriter struct {
+	bytes.Buffer
+	process func(in io.Reader, out io.Writer) error
+	dst     io.WriteCloser
+}
+
+// postprocess wraps dst to re-wrap (--wrap) or reformat (--pretty) the output, then
+// pipe it through the --postprocess command, add the --banner and check the characters
+// of the whole (--charset-check, --ascii-only), if set.
+// The banner comment style depends on the output file name.
+func (a *app) postprocess(dst io.WriteCloser, fileName string) io.WriteCloser {
+	if a.charset != "" {
+		dst = &postWriter{process: charsetChecker(a.charset, a.charsetEnc, a.asciiOnly), dst: dst}
+	}
+	if a.banner != "" {
+		if c, ok := comment(a.banner, fileName); ok {
+			dst = &postWriter{process: bannerInserter(c), dst: dst}
+		}
+	}
+	if a.postCommand != "" {
+		dst = &postWriter{process: runCommand(a.postCommand), dst: dst}
+	}
+	if a.pretty != "" {
+		dst = &postWriter{process: prettifier(a.pretty), dst: dst}
+	}
+	if a.wrap > 0 {
+		dst = &postWriter{process: wrapper(a.wrap, a.noWrapMarker), dst: dst}
+	}
+	return dst
+}
+
+// Close processes the output and closes the underlying writer.
+func (w *postWriter) Close() error {
+	if err := w.process(&w.Buffer, w.dst); err != nil {
+		abort(w.dst)
+		return err
+	}
+	return w.dst.Close()
+}
+
+// Abort discards the underlying output.
+func (w *postWriter) Abort() {
+	abort(w.dst)
+}
+
+func (w *postWriter) underlying() io.WriteCloser { return w.dst }
+
+// runCommand returns a process function piping the output through the command,
+// run with sh -c like the --exec commands (so it can use quotes and pipes).
+func runCommand(command string) func(io.Reader, io.Writer) error {
+	return func(in io.Reader, out io.Writer) error {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = in
+		cmd.Stdout = out
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && stderr.Len() > 0 {
+				return fmt.Errorf("postprocess %q: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+			}
+			return fmt.Errorf("postprocess %q: %w", command, err)
+		}
+		if stderr.Len() > 0 {
+			fmt.Fprint(os.Stderr, stderr.String())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPostprocess(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("no tr")
+	}
+	got := renderCSV(t, "Name\nAnn\n", "{{range .}}hello {{.Name}}{{end}}", "--postprocess", "tr a-z A-Z")
+	if got != "HELLO ANN" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPostprocessBannerCharset(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     *app
+		want    string
+		wantErr bool
+	}{
+		{"ascii-only", &app{banner: "Généré — do not edit", charset: "ascii", asciiOnly: true}, "# Genere - do not edit\necho\n", false},
+		{"charset-check", &app{banner: "Généré", charset: "ascii"}, "", true},
+		{"no check", &app{banner: "Généré"}, "# Généré\necho\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bufferCloser{}
+			w := tt.app.postprocess(buf, "out.sh")
+			io.WriteString(w, "echo\n")
+			err := w.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Close() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"cat", "a b\n", "a b\n", false},
+		{`tr -d "'"`, "it's\n", "its\n", false},
+		{"sed 's/a b/c/' | tr c C", "a b\n", "C\n", false},
+		{"exit 3", "", "", true},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		err := runCommand(tt.command)(strings.NewReader(tt.in), &out)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("runCommand(%q) error = %v, want error %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("runCommand(%q) = %q, want %q", tt.command, out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// prettifier returns a process function re-indenting the output in the given format
+// (json or yaml). An output that can not be parsed is an error.
+func prettifier(format string) func(io.Reader, io.Writer) error {
+	if format == "yaml" {
+		return prettyYAML
+	}
+	return prettyJSON
+}
+
+// prettyJSON re-indents a JSON output with two spaces.
+func prettyJSON(in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return fmt.Errorf("pretty json: %w", err)
+	}
+	// json.Indent keeps the trailing whitespace of the output, ended by a single newline here
+	buf.Truncate(len(bytes.TrimRight(buf.Bytes(), " \t\r\n")))
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// prettyYAML re-indents all the documents of a YAML output with two spaces,
+// in block style. The comments are preserved.
+func prettyYAML(in io.Reader, out io.Writer) error {
+	dec := yaml.NewDecoder(in)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("pretty yaml: %w", err)
+		}
+		blockStyle(&doc)
+		if err := enc.Encode(&doc); err != nil {
+			return fmt.Errorf("pretty yaml: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("pretty yaml: %w", err)
+	}
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// blockStyle turns the flow mappings and sequences of the YAML tree into block ones.
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode {
+		n.Style &^= yaml.FlowStyle
+	}
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
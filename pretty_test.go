@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	var out strings.Builder
+	if err := prettyJSON(strings.NewReader(`{"a":[1,2],"b":{}}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}\n"; out.String() != want {
+		t.Errorf("prettyJSON = %q, want %q", out.String(), want)
+	}
+	out.Reset()
+	if err := prettyJSON(strings.NewReader("[1]\n\n \r\n"), &out); err != nil || out.String() != "[\n  1\n]\n" {
+		t.Errorf("trailing whitespace: prettyJSON = %q, %v", out.String(), err)
+	}
+	if err := prettyJSON(strings.NewReader(`{"a":`), &out); err == nil {
+		t.Error("invalid json: no error")
+	}
+}
+
+func TestPrettyYAML(t *testing.T) {
+	var out strings.Builder
+	in := "# note\na: {b: 1, c: [x, y]}\n---\n- 1\n"
+	if err := prettyYAML(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "# note\na:\n  b: 1\n  c:\n    - x\n    - y\n---\n- 1\n"
+	if out.String() != want {
+		t.Errorf("prettyYAML = %q, want %q", out.String(), want)
+	}
+	if err := prettyYAML(strings.NewReader("a: [1"), &out); err == nil {
+		t.Error("invalid yaml: no error")
+	}
+}
+
+func TestPrettyRender(t *testing.T) {
+	got := renderCSV(t, "Name\nAnn\n", `[{{range .}}{"name":"{{.Name}}"}{{end}}]`, "--pretty", "json")
+	if want := "[\n  {\n    \"name\": \"Ann\"\n  }\n]\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
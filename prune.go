@@ -0,0 +1,99 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// keepPattern returns the glob matching, in an output directory, the files generated
+// by the --out template: the --keep-pattern, the SHA-256 names with --name-by-hash,
+// or the file name of --out with every template action replaced by *.
+func (a *app) keepPattern() string {
+	if a.keepGlob != "" {
+		return a.keepGlob
+	}
+	if a.nameByHash {
+		return strings.Repeat("[0-9a-f]", sha256.Size*2) + globEscape(a.hashExt)
+	}
+	name := filepath.Base(a.outPath)
+	action := regexp.MustCompile(regexp.QuoteMeta(a.leftDelim) + `.*?` + regexp.QuoteMeta(a.rightDelim))
+	var b strings.Builder
+	last := 0
+	for _, m := range action.FindAllStringIndex(name, -1) {
+		b.WriteString(globEscape(name[last:m[0]]))
+		b.WriteByte('*')
+		last = m[1]
+	}
+	b.WriteString(globEscape(name[last:]))
+	return b.String()
+}
+
+// globEscape escapes the glob special characters of s.
+func globEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(s)
+}
+
+// pruneOutputs keeps, in every directory of the outputs, the --keep-last most recent
+// (by modification time) files matching the keep pattern and removes the others.
+// The outputs of the run (even unchanged) and the symbolic links are never removed.
+// In dry-run mode the files are only listed.
+func (a *app) pruneOutputs() error {
+	if a.keepLast <= 0 || len(a.written) == 0 {
+		return nil
+	}
+	pattern := a.keepPattern()
+	var dirs []string
+	keep := make(map[string]bool, len(a.written))
+	for _, name := range a.written {
+		keep[filepath.Clean(name)] = true
+		if dir := filepath.Dir(name); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	var failures int
+	for _, dir := range dirs {
+		matches, err := filepath.Glob(filepath.Join(globEscape(dir), pattern))
+		if err != nil {
+			return fmt.Errorf("--keep-pattern: %w", err)
+		}
+		type file struct {
+			name string
+			info os.FileInfo
+		}
+		var files []file
+		for _, name := range matches {
+			info, err := os.Lstat(name)
+			if err == nil && info.Mode().IsRegular() {
+				files = append(files, file{name, info})
+			}
+		}
+		slices.SortStableFunc(files, func(f1, f2 file) int {
+			return cmp.Compare(f2.info.ModTime().UnixNano(), f1.info.ModTime().UnixNano())
+		})
+		for i, f := range files {
+			if i < a.keepLast || keep[filepath.Clean(f.name)] {
+				continue
+			}
+			if a.dryRun {
+				a.info("would remove %s (--keep-last)\n", f.name)
+				continue
+			}
+			if err := os.Remove(f.name); err != nil {
+				failures++
+				fmt.Fprintf(os.Stderr, "  %v\n", err)
+				continue
+			}
+			a.debug("removed %s (--keep-last)\n", f.name)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d old outputs could not be removed (--keep-last)", failures)
+	}
+	return nil
+}
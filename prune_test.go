@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepPattern(t *testing.T) {
+	tests := []struct {
+		out, glob, want string
+	}{
+		{"reports/{{.Key}}/r_{{.Date}}.pdf", "", "r_*.pdf"},
+		{"out_{{.N}}_{{.M}}.txt", "", "out_*_*.txt"},
+		{"plain.txt", "", "plain.txt"},
+		{"a[1]?_{{.N}}*.txt", "", `a\[1]\?_*\*.txt`},
+		{"r_{{.N}}.txt", "r_*.txt.gz", "r_*.txt.gz"},
+	}
+	for _, tt := range tests {
+		a := &app{outPath: tt.out, keepGlob: tt.glob, leftDelim: "{{", rightDelim: "}}"}
+		if got := a.keepPattern(); got != tt.want {
+			t.Errorf("keepPattern(%q, %q) = %q, want %q", tt.out, tt.glob, got, tt.want)
+		}
+	}
+}
+
+func TestGlobEscape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain_1.txt", "plain_1.txt"},
+		{"a*b?c[d]", `a\*b\?c\[d]`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range tests {
+		if got := globEscape(tt.in); got != tt.want {
+			t.Errorf("globEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if ok, err := filepath.Match(globEscape(tt.in), tt.in); !ok || err != nil {
+			t.Errorf("globEscape(%q) does not match itself: %v", tt.in, err)
+		}
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"r_old1.txt", "r_old2.txt", "notes.txt"} {
+		path := filepath.Join(dir, "out", name)
+		writeFile(t, path, "old")
+		mtime := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "out", "r_{{.Name}}.txt"), "--keep-last", "2"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"r_Ann.txt": "Ann", "r_old2.txt": "old", "notes.txt": "old"}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestPruneOutputsKeepsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "o_old.txt")
+	a := filepath.Join(dir, "o_A.txt")
+	b := filepath.Join(dir, "o_B.txt")
+	now := time.Now()
+	for i, name := range []string{old, a, b} {
+		if err := os.WriteFile(name, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// o_A.txt was left unchanged by --if-changed, only o_B.txt was rewritten
+	app := &app{
+		outPath:   filepath.Join(dir, "o_{{.Name}}.txt"),
+		leftDelim: "{{", rightDelim: "}}",
+		keepLast: 1,
+	}
+	app.generated(a, false)
+	app.generated(b, true)
+	if err := app.pruneOutputs(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{old: false, a: true, b: true} {
+		_, err := os.Stat(name)
+		if got := err == nil; got != want {
+			t.Errorf("%s exists: got %v, want %v", filepath.Base(name), got, want)
+		}
+	}
+}
+
+func TestKeepLastTree(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	writeFile(t, filepath.Join(dir, "tree", "{{.Name}}.txt"), "{{.Name}}")
+	writeFile(t, filepath.Join(dir, "out", "notes.txt"), "mine")
+	err := runCLI("-i", csv, "-t", filepath.Join(dir, "tree"), "-o", filepath.Join(dir, "out"), "--keep-last", "1")
+	if err == nil {
+		t.Error("--keep-last with a template tree: no error")
+	}
+	want := map[string]string{"notes.txt": "mine"}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestKeepLastByHash(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	writeFile(t, filepath.Join(dir, "store", "notes.txt"), "mine")
+	old := filepath.Join(dir, "store", strings.Repeat("0", 64)+".txt")
+	writeFile(t, old, "old")
+	mtime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "store"), "--name-by-hash", "--hash-ext", ".txt", "--keep-last", "1"); err != nil {
+		t.Fatal(err)
+	}
+	got := readTree(t, filepath.Join(dir, "store"))
+	if _, ok := got[filepath.Base(old)]; ok || got["notes.txt"] != "mine" || len(got) != 2 {
+		t.Errorf("outputs = %v, want notes.txt and the Ann hash", got)
+	}
+}
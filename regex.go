@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// regexCache keeps the compiled regexExtract patterns, as they are used for every row.
+var regexCache sync.Map
+
+// regexExtract returns the named groups of the first match of the pattern in s:
+// (regexExtract `(?P<area>\d{3})-(?P<num>\d+)` .Phone).area.
+// Without a match, all groups are empty.
+func regexExtract(pattern string, s any) (map[string]string, error) {
+	var re *regexp.Regexp
+	if cached, ok := regexCache.Load(pattern); ok {
+		re = cached.(*regexp.Regexp)
+	} else {
+		var err error
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("regexExtract: %w", err)
+		}
+		regexCache.Store(pattern, re)
+	}
+	groups := make(map[string]string)
+	match := re.FindStringSubmatch(fmt.Sprint(s))
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		groups[name] = ""
+		if match != nil {
+			groups[name] = match[i]
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegexExtract(t *testing.T) {
+	const pattern = `(?P<area>\d{3})-(?P<num>\d+)`
+	got, err := regexExtract(pattern, "tel: 555-1234")
+	if want := map[string]string{"area": "555", "num": "1234"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("match = %v, %v; want %v", got, err, want)
+	}
+	got, err = regexExtract(pattern, 42)
+	if want := map[string]string{"area": "", "num": ""}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("no match = %v, %v; want %v", got, err, want)
+	}
+	if _, err := regexExtract(`(?P<x`, "x"); err == nil {
+		t.Error("bad pattern: no error")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseRenames parses the --rename values: old=new pairs or mapping files,
+// CSV files of old,new lines. The later values take precedence.
+func parseRenames(specs []string) (map[string]string, error) {
+	renames := make(map[string]string)
+	for _, spec := range specs {
+		if _, err := os.Stat(spec); err == nil {
+			if err := readRenames(spec, renames); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		old, name, ok := strings.Cut(spec, "=")
+		if !ok || old == "" || name == "" {
+			return nil, fmt.Errorf("invalid rename %q (expected old=new or a mapping file)", spec)
+		}
+		renames[old] = name
+	}
+	return renames, nil
+}
+
+// readRenames adds the old,new lines of the mapping file to renames.
+func readRenames(path string, renames map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read rename map: %w", err)
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("read rename map %s: %w", path, err)
+	}
+	for _, r := range records {
+		renames[strings.TrimSpace(r[0])] = strings.TrimSpace(r[1])
+	}
+	return nil
+}
+
+// renameHeaders renames, in place, the headers listed in renames;
+// the other ones (and the missing old names) are left as they are.
+func renameHeaders(headers []string, renames map[string]string) {
+	for i, h := range headers {
+		if name, ok := renames[h]; ok {
+			headers[i] = name
+		}
+	}
+}
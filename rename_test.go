@@ -0,0 +1,31 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRenames(t *testing.T) {
+	mapping := filepath.Join(t.TempDir(), "names.csv")
+	writeFile(t, mapping, "# old,new\nCust No, customer\nAmt,amount\n")
+	renames, err := parseRenames([]string{"Amt=total", mapping, "Date=date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Cust No": "customer", "Amt": "amount", "Date": "date"}
+	if !reflect.DeepEqual(renames, want) {
+		t.Errorf("parseRenames = %v, want %v", renames, want)
+	}
+	headers := []string{"Cust No", "Amt", "Other"}
+	renameHeaders(headers, renames)
+	if want := []string{"customer", "amount", "Other"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("renameHeaders = %q, want %q", headers, want)
+	}
+	if _, err := parseRenames([]string{"Amt="}); err == nil {
+		t.Error("empty new name: no error")
+	}
+	if got := renderCSV(t, "Cust No\n42\n", "{{range .}}{{.customer}}{{end}}", "--rename", "Cust No=customer"); got != "42" {
+		t.Errorf("got %q", got)
+	}
+}
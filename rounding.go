@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rounding is a --round rule: the number of decimal places and the rounding mode
+// (half-up, half-even, down or up).
+type rounding struct {
+	places int
+	mode   string
+}
+
+// roundings holds the --round rules, per column and for all other columns.
+type roundings struct {
+	all     *rounding
+	columns map[string]rounding
+}
+
+// parseRoundings parses the --round values of the form [column=]places[:mode].
+func parseRoundings(specs []string) (roundings, error) {
+	var r roundings
+	for _, spec := range specs {
+		column, rule := "", spec
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			column, rule = spec[:i], spec[i+1:]
+			if column == "" {
+				return r, fmt.Errorf("invalid rounding %q (expected [column=]places[:mode])", spec)
+			}
+		}
+		places, mode, _ := strings.Cut(rule, ":")
+		n, err := strconv.Atoi(places)
+		if err != nil || n < 0 {
+			return r, fmt.Errorf("invalid decimal places in %q", spec)
+		}
+		switch mode {
+		case "":
+			mode = "half-up"
+		case "half-up", "half-even", "down", "up":
+		default:
+			return r, fmt.Errorf("unknown rounding mode %q in %q", mode, spec)
+		}
+		if column == "" {
+			r.all = &rounding{places: n, mode: mode}
+			continue
+		}
+		if r.columns == nil {
+			r.columns = make(map[string]rounding)
+		}
+		r.columns[column] = rounding{places: n, mode: mode}
+	}
+	return r, nil
+}
+
+// apply rounds the value of the column if it is a float64 covered by a rule,
+// else the value is returned unchanged.
+func (r roundings) apply(column string, v any) any {
+	f, ok := v.(float64)
+	if !ok {
+		return v
+	}
+	if rule, ok := r.columns[column]; ok {
+		return rule.round(f)
+	}
+	if r.all != nil {
+		return r.all.round(f)
+	}
+	return v
+}
+
+// round rounds f to the rule decimal places.
+// The scaled value is first cut to 15 significant digits, so 2.675 is seen as 267.5 and not 267.4999...
+func (r rounding) round(f float64) float64 {
+	scale := math.Pow10(r.places)
+	scaled, _ := strconv.ParseFloat(strconv.FormatFloat(f*scale, 'g', 15, 64), 64)
+	switch r.mode {
+	case "half-even":
+		scaled = math.RoundToEven(scaled)
+	case "down":
+		scaled = math.Trunc(scaled)
+	case "up":
+		if scaled < 0 {
+			scaled = math.Floor(scaled)
+		} else {
+			scaled = math.Ceil(scaled)
+		}
+	default:
+		scaled = math.Round(scaled)
+	}
+	return scaled / scale
+}
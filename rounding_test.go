@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		rule rounding
+		in   float64
+		want float64
+	}{
+		{rounding{2, "half-up"}, 2.675, 2.68},
+		{rounding{2, "half-up"}, -2.675, -2.68},
+		{rounding{1, "half-even"}, 0.25, 0.2},
+		{rounding{1, "half-even"}, 0.35, 0.4},
+		{rounding{0, "down"}, -3.7, -3},
+		{rounding{0, "up"}, -3.2, -4},
+		{rounding{1, "up"}, 1.01, 1.1},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.round(tt.in); got != tt.want {
+			t.Errorf("%+v.round(%v) = %v, want %v", tt.rule, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRoundings(t *testing.T) {
+	r, err := parseRoundings([]string{"2", "Rate=4:down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.apply("Price", 1.005); got != 1.01 {
+		t.Errorf("Price = %v, want 1.01", got)
+	}
+	if got := r.apply("Rate", 0.12349); got != 0.1234 {
+		t.Errorf("Rate = %v, want 0.1234", got)
+	}
+	if got := r.apply("Name", "1.005"); got != "1.005" {
+		t.Errorf("a string was rounded: %v", got)
+	}
+	for _, spec := range []string{"=2", "x", "-1", "2:bankers"} {
+		if _, err := parseRoundings([]string{spec}); err == nil {
+			t.Errorf("parseRoundings(%q): no error", spec)
+		}
+	}
+}
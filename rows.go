@@ -0,0 +1,285 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// filterRows keeps only the rows for which the filter expression renders to true.
+// The expression is a template (or the inside of a template action) evaluated
+// with the row as dot, e.g. `eq .city "Paris"`.
+func (a *app) filterRows(rows []map[string]any, funcs template.FuncMap) ([]map[string]any, error) {
+	expr := a.filter
+	if !strings.Contains(expr, a.leftDelim) {
+		expr = a.leftDelim + expr + a.rightDelim
+	}
+	tmpl, err := template.New("filter").Delims(a.leftDelim, a.rightDelim).Funcs(funcs).Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parse filter: %w", err)
+	}
+	kept := rows[:0]
+	var result strings.Builder
+	for idx, row := range rows {
+		result.Reset()
+		if err := tmpl.Execute(&result, row); err != nil {
+			return nil, fmt.Errorf("evaluate filter for row %d: %w", idx, err)
+		}
+		keep, err := parseCondition(result.String())
+		if err != nil {
+			return nil, fmt.Errorf("evaluate filter for row %d: %w", idx, err)
+		}
+		if keep {
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+// uniqueRows keeps the first of the rows having the same values in the given columns.
+func uniqueRows(rows []map[string]any, columns []string) ([]map[string]any, error) {
+	if len(rows) > 0 {
+		for _, col := range columns {
+			if _, ok := getField(rows[0], col); !ok {
+				return nil, fmt.Errorf("unique by unknown column %q", col)
+			}
+		}
+	}
+	seen := make(map[string]bool, len(rows))
+	kept := rows[:0]
+	var key strings.Builder
+	for _, row := range rows {
+		key.Reset()
+		for _, col := range columns {
+			v, _ := getField(row, col)
+			fmt.Fprintf(&key, "%v\x00", v)
+		}
+		if !seen[key.String()] {
+			seen[key.String()] = true
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+// parseCondition converts a rendered condition to a boolean.
+// Empty results and "<no value>" are false.
+func parseCondition(s string) (bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "<no value>" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("condition result %q is not a boolean", s)
+	}
+	return b, nil
+}
+
+// sortKey is one --sort-by key: a column, compared as string or number, ascending or descending.
+type sortKey struct {
+	column  string
+	numeric bool
+	desc    bool
+}
+
+// parseSortKeys parses the --sort-by values of the form column[:num][:desc].
+func parseSortKeys(specs []string) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		key := sortKey{column: parts[0]}
+		if key.column == "" {
+			return nil, fmt.Errorf("empty column in sort key %q", spec)
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "num":
+				key.numeric = true
+			case "str":
+				key.numeric = false
+			case "desc":
+				key.desc = true
+			case "asc":
+				key.desc = false
+			default:
+				return nil, fmt.Errorf("unknown option %q in sort key %q", opt, spec)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortRows sorts the rows (stable) using the sort keys in order.
+// In numeric comparison, values that are not numbers come after the numbers.
+func sortRows(rows []map[string]any, keys []sortKey) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if _, ok := getField(rows[0], key.column); !ok {
+			return fmt.Errorf("sort by unknown column %q", key.column)
+		}
+	}
+	slices.SortStableFunc(rows, func(r1, r2 map[string]any) int {
+		for _, key := range keys {
+			v1, _ := getField(r1, key.column)
+			v2, _ := getField(r2, key.column)
+			c := key.compare(v1, v2)
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// compare compares two values for the key (without the direction).
+// Typed values (see --infer-types) of the same kind are compared by value.
+func (k sortKey) compare(v1, v2 any) int {
+	if t1, ok := v1.(time.Time); ok {
+		if t2, ok := v2.(time.Time); ok {
+			return t1.Compare(t2)
+		}
+	}
+	f1, ok1 := toNumber(v1, k.numeric)
+	f2, ok2 := toNumber(v2, k.numeric)
+	switch {
+	case ok1 && ok2:
+		return cmp.Compare(f1, f2)
+	case k.numeric && ok1:
+		return -1
+	case k.numeric && ok2:
+		return 1
+	default:
+		return strings.Compare(fmt.Sprint(v1), fmt.Sprint(v2))
+	}
+}
+
+// toNumber returns the numeric value of v if it is a number,
+// or, if parse is set, a string containing a number.
+func toNumber(v any, parse bool) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		if parse {
+			f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			return f, err == nil
+		}
+	}
+	return 0, false
+}
+
+// column is a --columns entry: a CSV column and the field name used in the templates.
+type column struct {
+	name string
+	as   string
+}
+
+// parseColumns parses the --columns values of the form name[:newname].
+func parseColumns(specs []string) ([]column, error) {
+	cols := make([]column, 0, len(specs))
+	for _, spec := range specs {
+		name, as, ok := strings.Cut(spec, ":")
+		if !ok {
+			as = name
+		}
+		if name == "" || as == "" {
+			return nil, fmt.Errorf("invalid column %q", spec)
+		}
+		cols = append(cols, column{name: name, as: as})
+	}
+	return cols, nil
+}
+
+// selectColumns returns the indexes (in the CSV record) and the field names
+// of the selected columns, in the --columns order.
+func selectColumns(headers []string, cols []column) ([]int, []string, error) {
+	indexes := make([]int, len(cols))
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		indexes[i] = slices.Index(headers, col.name)
+		if indexes[i] < 0 {
+			return nil, nil, fmt.Errorf("unknown column %q", col.name)
+		}
+		names[i] = col.as
+	}
+	return indexes, names, nil
+}
+
+// fillDown replaces, in the given columns of the records, the empty cells
+// (or the naValues cells) by the last non empty cell above.
+// It runs on the raw cells, so the filled cells are parsed and validated as the others.
+func fillDown(records [][]string, headers []string, indexes []int, columns, naValues []string) error {
+	for _, col := range columns {
+		j := slices.Index(headers, col)
+		if j < 0 {
+			return fmt.Errorf("fill down unknown column %q", col)
+		}
+		i := indexes[j]
+		last := ""
+		for r, record := range records {
+			if len(record) == 0 {
+				continue
+			}
+			if i < len(record) && record[i] != "" && !slices.Contains(naValues, record[i]) {
+				last = record[i]
+				continue
+			}
+			if last == "" {
+				continue
+			}
+			if i >= len(record) {
+				record = append(record, make([]string, i+1-len(record))...)
+				records[r] = record
+			}
+			record[i] = last
+		}
+	}
+	return nil
+}
+
+// parseRowRange parses a --rows value FROM:TO of 1-based inclusive row numbers
+// (either bound may be omitted) and returns the corresponding offset and limit (0 for no limit).
+func parseRowRange(spec string) (offset, limit int, err error) {
+	from, to, ok := strings.Cut(spec, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid row range %q (expected FROM:TO)", spec)
+	}
+	first, last := 1, 0
+	if from != "" {
+		if first, err = strconv.Atoi(from); err != nil || first < 1 {
+			return 0, 0, fmt.Errorf("invalid first row in %q", spec)
+		}
+	}
+	if to != "" {
+		if last, err = strconv.Atoi(to); err != nil || last < first {
+			return 0, 0, fmt.Errorf("invalid last row in %q", spec)
+		}
+	}
+	if last == 0 {
+		return first - 1, 0, nil
+	}
+	return first - 1, last - first + 1, nil
+}
+
+// sliceRows skips the first offset rows and keeps at most limit rows (all if limit is 0).
+func sliceRows(rows []map[string]any, offset, limit int) []map[string]any {
+	rows = rows[min(offset, len(rows)):]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
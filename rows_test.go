@@ -0,0 +1,222 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    bool
+		wantErr bool
+	}{
+		{"true", true, false},
+		{" false\n", false, false},
+		{"1", true, false},
+		{"", false, false},
+		{"<no value>", false, false},
+		{"yes", false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseCondition(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseCondition(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	csv := "Name,Age\nAnn,17\nBob,18\nCyd,40\n"
+	tmpl := "{{range .}}{{.Name}} {{end}}"
+	if got, want := renderCSV(t, csv, tmpl, "--filter", "ge (toInt .Age) 18"), "Bob Cyd "; got != want {
+		t.Errorf("action filter: got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, tmpl, "--filter", `{{if eq .Name "Ann"}}true{{end}}`), "Ann "; got != want {
+		t.Errorf("template filter: got %q, want %q", got, want)
+	}
+}
+
+func TestParseSortKeys(t *testing.T) {
+	keys, err := parseSortKeys([]string{"City", "Amount:num:desc", "Name:desc:asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sortKey{{column: "City"}, {column: "Amount", numeric: true, desc: true}, {column: "Name"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("parseSortKeys = %+v, want %+v", keys, want)
+	}
+	for _, spec := range []string{":num", "City:reverse"} {
+		if _, err := parseSortKeys([]string{spec}); err == nil {
+			t.Errorf("parseSortKeys(%q): no error", spec)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	csv := "City,Amount\nParis,9\nLyon,10\nParis,100\nLyon,n/a\n"
+	tmpl := "{{range .}}{{.City}}:{{.Amount}} {{end}}"
+	tests := []struct {
+		keys, want string
+	}{
+		{"City", "Lyon:10 Lyon:n/a Paris:9 Paris:100 "},
+		{"Amount", "Lyon:10 Paris:100 Paris:9 Lyon:n/a "},
+		{"Amount:num", "Paris:9 Lyon:10 Paris:100 Lyon:n/a "},
+		{"City:desc,Amount:num:desc", "Paris:100 Paris:9 Lyon:n/a Lyon:10 "},
+	}
+	for _, tt := range tests {
+		if got := renderCSV(t, csv, tmpl, "--sort-by", tt.keys); got != tt.want {
+			t.Errorf("--sort-by %s: got %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	cols, err := parseColumns([]string{"Email:Mail", "Name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexes, names, err := selectColumns([]string{"Name", "Age", "Email"}, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(indexes, []int{2, 0}) || !slices.Equal(names, []string{"Mail", "Name"}) {
+		t.Errorf("selectColumns = %v, %v, want [2 0], [Mail Name]", indexes, names)
+	}
+	if _, _, err := selectColumns([]string{"Name"}, []column{{name: "Phone", as: "Phone"}}); err == nil {
+		t.Error("select an unknown column: no error")
+	}
+	for _, spec := range []string{"", ":Mail", "Email:"} {
+		if _, err := parseColumns([]string{spec}); err == nil {
+			t.Errorf("parseColumns(%q): no error", spec)
+		}
+	}
+}
+
+func TestColumnsRender(t *testing.T) {
+	got := renderCSV(t, "Name,Age,Email\nAnn,30,a@x\n", "{{range .}}{{len .}} {{.Mail}} {{.Name}}{{end}}", "--columns", "Email:Mail,Name")
+	// Mail, Name and the counter
+	if want := "3 a@x Ann"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFillDown(t *testing.T) {
+	records := [][]string{
+		{"x", "1"},
+		{"", "2"},
+		{"NA", "3"},
+		{},
+		{"y", "4"},
+		{"", ""},
+	}
+	headers := []string{"Group", "Value"}
+	if err := fillDown(records, headers, []int{0, 1}, []string{"Group"}, []string{"NA"}); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"x", "1"}, {"x", "2"}, {"x", "3"}, {}, {"y", "4"}, {"y", ""}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("fillDown = %q, want %q", records, want)
+	}
+	// selected and ragged columns
+	records = [][]string{{"a", "", "x"}, {"b"}}
+	if err := fillDown(records, []string{"Last"}, []int{2}, []string{"Last"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want = [][]string{{"a", "", "x"}, {"b", "", "x"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("fillDown = %q, want %q", records, want)
+	}
+	if err := fillDown(records, headers, []int{0, 1}, []string{"Missing"}, nil); err == nil {
+		t.Error("fillDown of an unknown column: no error")
+	}
+}
+
+func TestParseRowRange(t *testing.T) {
+	tests := []struct {
+		in            string
+		offset, limit int
+		wantErr       bool
+	}{
+		{"10:50", 9, 41, false},
+		{"1:1", 0, 1, false},
+		{":5", 0, 5, false},
+		{"3:", 2, 0, false},
+		{":", 0, 0, false},
+		{"5", 0, 0, true},
+		{"0:3", 0, 0, true},
+		{"5:4", 0, 0, true},
+		{"a:b", 0, 0, true},
+	}
+	for _, tt := range tests {
+		offset, limit, err := parseRowRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRowRange(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if offset != tt.offset || limit != tt.limit {
+			t.Errorf("parseRowRange(%q) = %d, %d, want %d, %d", tt.in, offset, limit, tt.offset, tt.limit)
+		}
+	}
+}
+
+func TestRowsRange(t *testing.T) {
+	csv := "N\n1\n2\n3\n4\n"
+	if got := renderCSV(t, csv, "{{range .}}{{.N}}{{end}}", "--rows", "2:3"); got != "23" {
+		t.Errorf("--rows 2:3: got %q", got)
+	}
+	if got := renderCSV(t, csv, "{{range .}}{{.N}}{{end}}", "--offset", "3", "--limit", "5"); got != "4" {
+		t.Errorf("--offset 3 --limit 5: got %q", got)
+	}
+}
+
+func TestSliceRows(t *testing.T) {
+	rows := []map[string]any{{"n": 1}, {"n": 2}, {"n": 3}}
+	tests := []struct {
+		offset, limit, first, count int
+	}{
+		{0, 0, 1, 3},
+		{1, 0, 2, 2},
+		{1, 1, 2, 1},
+		{0, 10, 1, 3},
+		{5, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		got := sliceRows(rows, tt.offset, tt.limit)
+		if len(got) != tt.count || len(got) > 0 && got[0]["n"] != tt.first {
+			t.Errorf("sliceRows(%d, %d) = %v, want %d rows from %d", tt.offset, tt.limit, got, tt.count, tt.first)
+		}
+	}
+}
+
+func TestUniqueRows(t *testing.T) {
+	rows := []map[string]any{
+		{"a": "x", "b": 1},
+		{"a": "x", "b": 2},
+		{"a": "y", "b": 1},
+		{"a": "x", "b": 1},
+	}
+	got, err := uniqueRows(rows, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{{"a": "x", "b": 1}, {"a": "x", "b": 2}, {"a": "y", "b": 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueRows = %v, want %v", got, want)
+	}
+	if _, err := uniqueRows(rows, []string{"c"}); err == nil {
+		t.Error("uniqueRows by an unknown column: no error")
+	}
+}
+
+func TestUnique(t *testing.T) {
+	csv := "City,Name\nParis,Ann\nLyon,Bob\nParis,Ann\nParis,Eve\n"
+	tmpl := "{{range .}}{{.Name}} {{end}}"
+	if got, want := renderCSV(t, csv, tmpl, "--unique"), "Ann Bob Eve "; got != want {
+		t.Errorf("--unique: got %q, want %q", got, want)
+	}
+	if got, want := renderCSV(t, csv, tmpl, "--unique-by", "City"), "Ann Bob "; got != want {
+		t.Errorf("--unique-by City: got %q, want %q", got, want)
+	}
+}
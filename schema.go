@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schema describes the expected columns of the CSV, used to parse and validate the cells.
+// Example schema file:
+//
+//	columns:
+//	  Name:
+//	    required: true
+//	  Age:
+//	    type: int
+//	  Birth:
+//	    type: date
+//	    layout: 02/01/2006
+//	  Status:
+//	    allowed: [active, inactive]
+//	  Tags:
+//	    type: json
+//	  Photo:
+//	    type: base64
+type schema struct {
+	Columns map[string]*columnSchema `yaml:"columns"`
+}
+
+// columnSchema describes one column: its type, the date layout,
+// whether a value is required and the allowed values.
+type columnSchema struct {
+	Type     string   `yaml:"type"`
+	Layout   string   `yaml:"layout"`
+	Required bool     `yaml:"required"`
+	Allowed  []string `yaml:"allowed"`
+}
+
+// jsonColumn is the schema of the --json-columns columns.
+var jsonColumn = &columnSchema{Type: "json"}
+
+// binaryColumn is the schema of the --binary-columns columns.
+var binaryColumn = &columnSchema{Type: "base64"}
+
+// loadSchema reads and validates the schema file.
+func loadSchema(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var s schema
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	for name, col := range s.Columns {
+		if col == nil {
+			s.Columns[name] = &columnSchema{Type: "string"}
+			continue
+		}
+		switch col.Type {
+		case "":
+			col.Type = "string"
+		case "string", "int", "float", "bool", "json", "base64":
+		case "date":
+			if col.Layout == "" {
+				col.Layout = time.DateOnly
+			}
+		default:
+			return nil, fmt.Errorf("schema: unknown type %q for column %s", col.Type, name)
+		}
+	}
+	return &s, nil
+}
+
+// column returns the schema of the named column, or nil if not described.
+func (s *schema) column(name string) *columnSchema {
+	if s == nil {
+		return nil
+	}
+	return s.Columns[name]
+}
+
+// checkHeaders verifies that all required columns are present.
+func (s *schema) checkHeaders(headers []string) error {
+	if s == nil {
+		return nil
+	}
+	var missing []string
+	for name, col := range s.Columns {
+		if col.Required && !slices.Contains(headers, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// parse validates the cell and converts it to the column type.
+// Empty cells of columns that are not required stay empty strings.
+func (c *columnSchema) parse(cell string) (any, error) {
+	if cell == "" {
+		if c.Required {
+			return nil, fmt.Errorf("required value is missing")
+		}
+		return cell, nil
+	}
+	if len(c.Allowed) > 0 && !slices.Contains(c.Allowed, cell) {
+		return nil, fmt.Errorf("value %q is not one of %s", cell, strings.Join(c.Allowed, ", "))
+	}
+	var v any
+	var err error
+	switch c.Type {
+	case "int":
+		v, err = strconv.Atoi(strings.TrimSpace(cell))
+	case "float":
+		v, err = strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	case "bool":
+		v, err = strconv.ParseBool(strings.TrimSpace(cell))
+	case "date":
+		v, err = time.Parse(c.Layout, strings.TrimSpace(cell))
+	case "json":
+		err = json.Unmarshal([]byte(cell), &v)
+	case "base64":
+		v, err = base64.StdEncoding.DecodeString(strings.TrimSpace(cell))
+	default:
+		v = cell
+	}
+	if err != nil {
+		return nil, fmt.Errorf("value %q is not a valid %s", cell, c.Type)
+	}
+	return v, nil
+}
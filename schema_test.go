@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnSchemaParse(t *testing.T) {
+	tests := []struct {
+		col     columnSchema
+		cell    string
+		want    any
+		wantErr bool
+	}{
+		{columnSchema{Type: "int"}, " 42 ", 42, false},
+		{columnSchema{Type: "int"}, "4.2", nil, true},
+		{columnSchema{Type: "float"}, "4.2", 4.2, false},
+		{columnSchema{Type: "bool"}, "true", true, false},
+		{columnSchema{Type: "date", Layout: "02/01/2006"}, "31/01/2024", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{columnSchema{Type: "date", Layout: time.DateOnly}, "31/01/2024", nil, true},
+		{columnSchema{Type: "string", Allowed: []string{"on", "off"}}, "on", "on", false},
+		{columnSchema{Type: "string", Allowed: []string{"on", "off"}}, "auto", nil, true},
+		{columnSchema{Type: "int"}, "", "", false},
+		{columnSchema{Type: "int", Required: true}, "", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.col.parse(tt.cell)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v parse(%q) error = %v", tt.col, tt.cell, err)
+			continue
+		}
+		if tm, ok := got.(time.Time); ok && tm.Equal(tt.want.(time.Time)) {
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%+v parse(%q) = %#v, want %#v", tt.col, tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.yaml")
+	writeFile(t, path, "columns:\n  Name:\n    required: true\n  Age:\n    type: int\n  Birth:\n    type: date\n  Note:\n")
+	s, err := loadSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.column("Birth").Layout != time.DateOnly || s.column("Note").Type != "string" || s.column("City") != nil {
+		t.Errorf("schema defaults: %+v", s.Columns)
+	}
+	if err := s.checkHeaders([]string{"Age", "Note"}); err == nil || !strings.Contains(err.Error(), "Name") {
+		t.Errorf("checkHeaders without Name: %v", err)
+	}
+
+	got := renderCSV(t, "Name,Age\nAnn,30\n", `{{range .}}{{printf "%T" .Age}}{{end}}`, "--schema", path)
+	if got != "int" {
+		t.Errorf("Age rendered as %s, want int", got)
+	}
+	csv := filepath.Join(dir, "bad.csv")
+	writeFile(t, csv, "Name,Age\nAnn,thirty\n,40\n")
+	err = runCLI("-i", csv, "-t", "x", "-o", filepath.Join(dir, "out.txt"), "--schema", path)
+	if err == nil || !strings.HasPrefix(err.Error(), "2 ") {
+		t.Errorf("invalid cells: error = %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	writeFile(t, bad, "columns:\n  Age:\n    type: integer\n")
+	if _, err := loadSchema(bad); err == nil {
+		t.Error("unknown type: no error")
+	}
+}
+
+func TestJSONColumns(t *testing.T) {
+	csv := "Name,Tags,Meta\nAnn,\"[\"\"a\"\",\"\"b\"\"]\",\"{\"\"age\"\":30}\"\n"
+	tmpl := `{{range .}}{{index .Tags 1}} {{.Meta.age}} {{len .Tags}}{{end}}`
+	if got, want := renderCSV(t, csv, tmpl, "--json-columns", "Tags,Meta"), "b 30 2"; got != want {
+		t.Errorf("--json-columns: got %q, want %q", got, want)
+	}
+
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.yaml")
+	writeFile(t, schema, "columns:\n  Meta:\n    type: json\n")
+	if got, want := renderCSV(t, csv, `{{range .}}{{.Meta.age}}{{end}}`, "--schema", schema), "30"; got != want {
+		t.Errorf("json schema type: got %q, want %q", got, want)
+	}
+
+	in := filepath.Join(dir, "bad.csv")
+	writeFile(t, in, "Tags\n[1,\n")
+	if err := runCLI("-i", in, "-t", "x", "-o", filepath.Join(dir, "out.txt"), "--json-columns", "Tags"); err == nil {
+		t.Error("invalid JSON cell: no error")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.starlark.net/starlark"
+)
+
+// scriptFuncs runs the Starlark script and returns its top-level functions
+// (except the ones starting with _) as template functions.
+func scriptFuncs(path string) (template.FuncMap, error) {
+	thread := &starlark.Thread{Name: "funcs", Print: func(_ *starlark.Thread, msg string) {
+		fmt.Fprintln(os.Stderr, msg)
+	}}
+	globals, err := starlark.ExecFile(thread, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("run --funcs script: %w", err)
+	}
+	globals.Freeze()
+	funcs := make(template.FuncMap)
+	for name, v := range globals {
+		fn, ok := v.(*starlark.Function)
+		if !ok || strings.HasPrefix(name, "_") {
+			continue
+		}
+		funcs[name] = scriptFunc(fn)
+	}
+	return funcs, nil
+}
+
+// scriptFunc wraps a Starlark function as a template function.
+func scriptFunc(fn *starlark.Function) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		sargs := make(starlark.Tuple, len(args))
+		for i, arg := range args {
+			sargs[i] = toStarlark(arg)
+		}
+		thread := &starlark.Thread{Name: fn.Name()}
+		result, err := starlark.Call(thread, fn, sargs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		return fromStarlark(result), nil
+	}
+}
+
+// toStarlark converts a template value (a cell, a row, a list...) to a Starlark value.
+// Dates become ISO strings and unknown types their string representation.
+func toStarlark(v any) starlark.Value {
+	switch x := v.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(x)
+	case int:
+		return starlark.MakeInt(x)
+	case int64:
+		return starlark.MakeInt64(x)
+	case float64:
+		return starlark.Float(x)
+	case string:
+		return starlark.String(x)
+	case time.Time:
+		return starlark.String(x.Format(time.RFC3339))
+	case []any:
+		list := make([]starlark.Value, len(x))
+		for i, item := range x {
+			list[i] = toStarlark(item)
+		}
+		return starlark.NewList(list)
+	case []string:
+		list := make([]starlark.Value, len(x))
+		for i, item := range x {
+			list[i] = starlark.String(item)
+		}
+		return starlark.NewList(list)
+	case []map[string]any:
+		list := make([]starlark.Value, len(x))
+		for i, item := range x {
+			list[i] = toStarlark(item)
+		}
+		return starlark.NewList(list)
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(x))
+		for _, k := range keys {
+			dict.SetKey(starlark.String(k), toStarlark(x[k]))
+		}
+		return dict
+	default:
+		return starlark.String(fmt.Sprint(v))
+	}
+}
+
+// fromStarlark converts a Starlark value returned by a script function to a template value.
+func fromStarlark(v starlark.Value) any {
+	switch x := v.(type) {
+	case starlark.NoneType:
+		return nil
+	case starlark.Bool:
+		return bool(x)
+	case starlark.Int:
+		if n, ok := x.Int64(); ok {
+			return int(n)
+		}
+		return x.String()
+	case starlark.Float:
+		return float64(x)
+	case starlark.String:
+		return string(x)
+	case *starlark.List:
+		list := make([]any, x.Len())
+		for i := range list {
+			list[i] = fromStarlark(x.Index(i))
+		}
+		return list
+	case starlark.Tuple:
+		list := make([]any, len(x))
+		for i, item := range x {
+			list[i] = fromStarlark(item)
+		}
+		return list
+	case *starlark.Dict:
+		m := make(map[string]any, x.Len())
+		for _, item := range x.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				key = item[0].String()
+			}
+			m[key] = fromStarlark(item[1])
+		}
+		return m
+	default:
+		return x.String()
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScriptFuncs(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "funcs.star")
+	writeFile(t, script, `
+def initials(name):
+    return "".join([w[0] for w in name.split(" ")])
+
+def total(rows, col):
+    n = 0
+    for r in rows:
+        n += int(r[col])
+    return n
+
+def _helper():
+    return 0
+`)
+	funcs, err := scriptFuncs(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := funcs["_helper"]; ok {
+		t.Error("the private function _helper is exported")
+	}
+	got := renderCSV(t, "Name,Qty\nAnn Lee,2\nBob Ray,3\n", `{{range .}}{{initials .Name}} {{end}}{{total .Rows "Qty"}}`, "--funcs", script)
+	if want := "AL BR 5"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	initials := funcs["initials"].(func(...any) (any, error))
+	if _, err := initials(1); err == nil {
+		t.Error("initials(1): no error")
+	}
+}
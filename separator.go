@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseSeparator decodes the --csv-sep value, where Go escapes like \t or \x1f
+// are allowed. It returns the separator rune, or the multi-character separator.
+func parseSeparator(s string) (rune, string, error) {
+	if strings.Contains(s, `\`) {
+		unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`)
+		if err != nil {
+			return 0, "", errors.New("invalid escape sequence")
+		}
+		s = unquoted
+	}
+	if s == "" {
+		return 0, "", errors.New("the separator is empty")
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == len(s) {
+		return r, "", nil
+	}
+	return 0, s, nil
+}
+
+// translateSeparator replaces the multi-character separator sep, outside of the
+// quoted fields, by a single character not used in the content nor equal to
+// the comment character. As in encoding/csv, a quote opens a quoted field only
+// at the start of the field, and a doubled quote inside it is an escaped quote.
+// It returns the translated content and the character to use as separator.
+func translateSeparator(content, sep string, comment rune) (string, rune, error) {
+	comma := rune(0x1f) // unit separator
+	for comma == comment || strings.ContainsRune(content, comma) {
+		comma++
+		if comma == '\n' || comma == '\r' || comma == '"' {
+			comma++
+		}
+		if comma > 0x10FFFF {
+			return "", 0, errors.New("no free character to translate the separator")
+		}
+	}
+	var b strings.Builder
+	b.Grow(len(content))
+	quoted, fieldStart := false, true
+	for i := 0; i < len(content); {
+		switch {
+		case quoted && strings.HasPrefix(content[i:], `""`):
+			b.WriteString(`""`)
+			i += 2
+		case content[i] == '"' && (quoted || fieldStart):
+			quoted = !quoted
+			fieldStart = false
+			b.WriteByte('"')
+			i++
+		case !quoted && strings.HasPrefix(content[i:], sep):
+			b.WriteRune(comma)
+			fieldStart = true
+			i += len(sep)
+		default:
+			fieldStart = !quoted && content[i] == '\n'
+			b.WriteByte(content[i])
+			i++
+		}
+	}
+	return b.String(), comma, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestParseSeparator(t *testing.T) {
+	tests := []struct {
+		in    string
+		r     rune
+		multi string
+	}{
+		{",", ',', ""},
+		{`\t`, '\t', ""},
+		{`\x1f`, 0x1f, ""},
+		{"||", 0, "||"},
+		{`\t\t`, 0, "\t\t"},
+		{`"`, '"', ""},
+	}
+	for _, tt := range tests {
+		r, multi, err := parseSeparator(tt.in)
+		if err != nil || r != tt.r || multi != tt.multi {
+			t.Errorf("parseSeparator(%q) = %q, %q, %v", tt.in, r, multi, err)
+		}
+	}
+	for _, in := range []string{"", `\q`} {
+		if _, _, err := parseSeparator(in); err == nil {
+			t.Errorf("parseSeparator(%q): no error", in)
+		}
+	}
+}
+
+func TestMultiSeparator(t *testing.T) {
+	got := renderCSV(t, "Name||Note\nAnn||\"a||b\"\n", "{{range .}}{{.Name}}={{.Note}}{{end}}", "--csv-sep", "||")
+	if got != "Ann=a||b" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTranslateSeparator(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"a||b\n", "a\x1fb\n"},
+		{"\"a||b\"||c\n", "\"a||b\"\x1fc\n"},
+		{"\"a\"\"||b\"||c\n", "\"a\"\"||b\"\x1fc\n"},
+		// a quote inside an unquoted field does not open a quoted field
+		{"5\"||x\ny||z\n", "5\"\x1fx\ny\x1fz\n"},
+		{"a||\"b\nc\"\n\"d\"||e\n", "a\x1f\"b\nc\"\n\"d\"\x1fe\n"},
+	}
+	for _, tt := range tests {
+		got, comma, err := translateSeparator(tt.in, "||", 0)
+		if err != nil || comma != 0x1f || got != tt.want {
+			t.Errorf("translateSeparator(%q) = %q, %q, %v, want %q", tt.in, got, comma, err, tt.want)
+		}
+	}
+	if _, comma, _ := translateSeparator("a||b\n", "||", 0x1f); comma == 0x1f {
+		t.Error("the separator is the comment character")
+	}
+}
+
+func TestMultiSeparatorInnerQuote(t *testing.T) {
+	got := renderCSV(t, "Size||Note\n5\"||ok\n", "{{range .}}{{.Size}}={{.Note}}{{end}}", "--csv-sep", "||", "--lazy-quotes")
+	if got != "5\"=ok" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// writeSplit is writeSingle with --split-size: the rows (or groups) are split in
+// consecutive chunks, each rendered with the whole template into its own file
+// (out.1.txt, out.2.txt...) of at most splitSize bytes. A single row (or group)
+// rendering bigger than the size is still written alone in its file.
+// The size is the one of the rendered template, before the banner, the byte order
+// mark, the encoding, the line ending conversion and the encryption.
+func (a *app) writeSplit(tmpl executor, data any, rows []map[string]any) error {
+	var count int
+	var chunk func(i, j int) any
+	var chunkRows func(i, j int) []map[string]any
+	switch d := data.(type) {
+	case []group:
+		count = len(d)
+		chunk = func(i, j int) any { return d[i:j] }
+		chunkRows = func(i, j int) []map[string]any {
+			var rows []map[string]any
+			for _, g := range d[i:j] {
+				rows = append(rows, g.Rows...)
+			}
+			return rows
+		}
+	default:
+		count = len(rows)
+		chunk = func(i, j int) any { return dataset(rows[i:j]) }
+		chunkRows = func(i, j int) []map[string]any { return rows[i:j] }
+	}
+	render := func(i, j int) ([]byte, error) {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, chunk(i, j)); err != nil {
+			return nil, fmt.Errorf("execute template: %w", err)
+		}
+		return b.Bytes(), nil
+	}
+
+	if !a.progress {
+		a.info("%s\n", a.savedHeader())
+	}
+	for i, part, hint := 0, 1, 1; i < count || part == 1; part++ {
+		j, content, err := largestChunk(render, i, count, a.splitSize, hint)
+		if err != nil {
+			return err
+		}
+		name := partName(a.outPath, part)
+		if len(content) > a.splitSize {
+			a.info("%s is bigger than --split-size (%d bytes)\n", name, len(content))
+		}
+		f, err := a.writer(name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(content); err != nil {
+			abort(f)
+			a.recordFailure(name, chunkRows(i, j), err)
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			a.recordFailure(name, chunkRows(i, j), err)
+			return fmt.Errorf("close %s: %w", name, err)
+		}
+		a.recordOutput(name, chunkRows(i, j), f)
+		if err := a.recordAudit(name, outputSink(name), chunkRows(i, j)); err != nil {
+			return err
+		}
+		if err := a.recordLatest(name, nil); err != nil {
+			return err
+		}
+		a.saved(name, f)
+		i, hint = j, j-i
+	}
+	a.changeSummary()
+	return nil
+}
+
+// largestChunk returns the end j of the largest chunk [i, j) of the count items
+// rendering in at most size bytes (but at least one item), with its rendering.
+// The search starts with hint items (the length of the previous chunk, as the
+// chunks are usually of similar lengths), gallops up or down from there until
+// the limit is crossed, then bisects. With the right hint, only two renderings
+// are needed.
+func largestChunk(render func(i, j int) ([]byte, error), i, count, size, hint int) (int, []byte, error) {
+	renders := make(map[int][]byte)
+	fits := func(j int) (bool, error) {
+		c, ok := renders[j]
+		if !ok {
+			var err error
+			if c, err = render(i, j); err != nil {
+				return false, err
+			}
+			renders[j] = c
+		}
+		return len(c) <= size, nil
+	}
+	// good is the end of the largest chunk known to fit (i if none), bad the end
+	// of the smallest chunk known to be too big (count+1 if none)
+	good, bad := i, count+1
+	j := min(count, i+max(hint, 1))
+	ok, err := fits(j)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ok {
+		good = j
+		for step := 1; good < count; step *= 2 {
+			j = min(count, good+step)
+			if ok, err = fits(j); err != nil {
+				return 0, nil, err
+			}
+			if !ok {
+				bad = j
+				break
+			}
+			good = j
+		}
+	} else {
+		bad = j
+		for step := 1; bad > i+1; step *= 2 {
+			j = max(i+1, bad-step)
+			if ok, err = fits(j); err != nil {
+				return 0, nil, err
+			}
+			if ok {
+				good = j
+				break
+			}
+			bad = j
+		}
+	}
+	for good > i && bad-good > 1 {
+		mid := (good + bad) / 2
+		if ok, err = fits(mid); err != nil {
+			return 0, nil, err
+		}
+		if ok {
+			good = mid
+		} else {
+			bad = mid
+		}
+	}
+	// A single item too big is still a chunk
+	good = max(good, min(i+1, count))
+	if _, err := fits(good); err != nil {
+		return 0, nil, err
+	}
+	return good, renders[good], nil
+}
+
+// partName returns the name of the nth part of the output: out.txt gives out.n.txt.
+func partName(fileName string, n int) string {
+	ext := filepath.Ext(fileName)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(fileName, ext), n, ext)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLargestChunk(t *testing.T) {
+	// every item renders in 10 bytes
+	item := strings.Repeat("x", 10)
+	tests := []struct {
+		i, count, size, want int
+	}{
+		{0, 100, 35, 3},
+		{0, 100, 1000, 100},
+		{0, 100, 5, 1}, // at least one item
+		{40, 100, 250, 65},
+		{99, 100, 20, 100},
+		{0, 7, 60, 6},
+		{0, 0, 60, 0}, // no rows, a single empty part
+	}
+	for _, tt := range tests {
+		calls := 0
+		render := func(i, j int) ([]byte, error) {
+			calls++
+			return []byte(strings.Repeat(item, j-i)), nil
+		}
+		j, content, err := largestChunk(render, tt.i, tt.count, tt.size, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j != tt.want || len(content) != 10*(j-tt.i) {
+			t.Errorf("largestChunk(%d, %d, %d) = %d, %d bytes, want %d", tt.i, tt.count, tt.size, j, len(content), tt.want)
+		}
+		if calls > 20 {
+			t.Errorf("largestChunk(%d, %d, %d): %d renders", tt.i, tt.count, tt.size, calls)
+		}
+	}
+}
+
+func TestLargestChunkHint(t *testing.T) {
+	item := strings.Repeat("x", 10)
+	for _, hint := range []int{1, 2, 3, 4, 50, 1000} {
+		calls := 0
+		render := func(i, j int) ([]byte, error) {
+			calls++
+			return []byte(strings.Repeat(item, j-i)), nil
+		}
+		j, _, err := largestChunk(render, 10, 100, 35, hint)
+		if err != nil || j != 13 {
+			t.Errorf("hint %d: largestChunk = %d, %v, want 13", hint, j, err)
+		}
+		if hint == 3 && calls != 2 {
+			t.Errorf("right hint: %d renders, want 2", calls)
+		}
+	}
+}
+
+func TestPartName(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"out.txt", 2, "out.2.txt"},
+		{"dir/out", 1, "dir/out.1"},
+		{"a.b/c.tar.gz", 3, "a.b/c.tar.3.gz"},
+	}
+	for _, tt := range tests {
+		if got := partName(tt.in, tt.n); got != tt.want {
+			t.Errorf("partName(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSize(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\nEve\n")
+	// header 4 bytes + 4 bytes by row
+	if err := runCLI("-i", csv, "-t", "Who\n{{range .}}{{.Name}}\n{{end}}", "-o", filepath.Join(dir, "out.txt"), "--split-size", "12"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"in.csv": "Name\nAnn\nBob\nEve\n", "out.1.txt": "Who\nAnn\nBob\n", "out.2.txt": "Who\nEve\n"}
+	if got := readTree(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %q, want %q", got, want)
+	}
+}
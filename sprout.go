@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/go-sprout/sprout"
+	"github.com/go-sprout/sprout/group/all"
+	"github.com/go-sprout/sprout/registry/checksum"
+	"github.com/go-sprout/sprout/registry/conversion"
+	"github.com/go-sprout/sprout/registry/encoding"
+	"github.com/go-sprout/sprout/registry/env"
+	"github.com/go-sprout/sprout/registry/filesystem"
+	"github.com/go-sprout/sprout/registry/maps"
+	"github.com/go-sprout/sprout/registry/network"
+	"github.com/go-sprout/sprout/registry/numeric"
+	"github.com/go-sprout/sprout/registry/random"
+	"github.com/go-sprout/sprout/registry/reflect"
+	"github.com/go-sprout/sprout/registry/regexp"
+	"github.com/go-sprout/sprout/registry/semver"
+	"github.com/go-sprout/sprout/registry/slices"
+	"github.com/go-sprout/sprout/registry/std"
+	"github.com/go-sprout/sprout/registry/strings"
+	"github.com/go-sprout/sprout/registry/time"
+	"github.com/go-sprout/sprout/registry/uniqueid"
+)
+
+// sproutRegistries are the sprout function groups selectable with --funcs-groups
+// (the registries of the sprout all group, and maths as an alias of numeric).
+var sproutRegistries = map[string]func() sprout.Registry{
+	"checksum":   func() sprout.Registry { return checksum.NewRegistry() },
+	"conversion": func() sprout.Registry { return conversion.NewRegistry() },
+	"encoding":   func() sprout.Registry { return encoding.NewRegistry() },
+	"env":        func() sprout.Registry { return env.NewRegistry() },
+	"filesystem": func() sprout.Registry { return filesystem.NewRegistry() },
+	"maps":       func() sprout.Registry { return maps.NewRegistry() },
+	"network":    func() sprout.Registry { return network.NewRegistry() },
+	"numeric":    func() sprout.Registry { return numeric.NewRegistry() },
+	"maths":      func() sprout.Registry { return numeric.NewRegistry() },
+	"random":     func() sprout.Registry { return random.NewRegistry() },
+	"reflect":    func() sprout.Registry { return reflect.NewRegistry() },
+	"regexp":     func() sprout.Registry { return regexp.NewRegistry() },
+	"semver":     func() sprout.Registry { return semver.NewRegistry() },
+	"slices":     func() sprout.Registry { return slices.NewRegistry() },
+	"std":        func() sprout.Registry { return std.NewRegistry() },
+	"strings":    func() sprout.Registry { return strings.NewRegistry() },
+	"time":       func() sprout.Registry { return time.NewRegistry() },
+	"uniqueid":   func() sprout.Registry { return uniqueid.NewRegistry() },
+}
+
+// sproutFuncMap creates a template.FuncMap with the functions of the given sprout groups,
+// or of all groups if groups is nil.
+func sproutFuncMap(groups []string) (template.FuncMap, error) {
+	handler := sprout.New()
+	if groups == nil {
+		if err := handler.AddGroups(all.RegistryGroup()); err != nil {
+			return nil, fmt.Errorf("register sprout functions: %w", err)
+		}
+		return handler.Build(), nil
+	}
+	added := make(map[string]bool, len(groups))
+	for _, name := range groups {
+		registry, ok := sproutRegistries[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function group %q", name)
+		}
+		if name == "maths" {
+			name = "numeric"
+		}
+		if added[name] {
+			continue
+		}
+		added[name] = true
+		if err := handler.AddRegistry(registry()); err != nil {
+			return nil, fmt.Errorf("register sprout functions: %w", err)
+		}
+	}
+	return handler.Build(), nil
+}
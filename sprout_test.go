@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestSproutFuncMap(t *testing.T) {
+	funcs, err := sproutFuncMap([]string{"strings", "maths", "numeric"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := funcs["toUpper"]; !ok {
+		t.Error("strings group: no toUpper")
+	}
+	if _, ok := funcs["uuidv4"]; ok {
+		t.Error("uniqueid group loaded but not asked for")
+	}
+	none, err := sproutFuncMap([]string{})
+	if err != nil || len(none) != 0 {
+		t.Errorf("no groups: %d functions, %v", len(none), err)
+	}
+	if _, err := sproutFuncMap([]string{"stringz"}); err == nil {
+		t.Error("unknown group: no error")
+	}
+	if got := renderCSV(t, "Ok\nyes\n", "{{range .}}{{symbol .Ok}}{{end}}", "--no-funcs"); got != "✓" {
+		t.Errorf("--no-funcs: got %q, want the csvplate functions only", got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// input reads an input with content but, with --stdin-sep and both the CSV and
+// the template read from stdin, returns its part of stdin: the CSV (part 0)
+// before the separator line and the template (part 1) after it.
+func (a *app) input(path string, part int) (string, error) {
+	if path != "-" || a.stdinSep == "" || a.csvPath != a.templatePath {
+		return a.content(path)
+	}
+	if a.stdinParts == nil {
+		text, err := a.content("-")
+		if err != nil {
+			return "", err
+		}
+		csvPart, tmplPart, err := splitStdin(text, a.stdinSep)
+		if err != nil {
+			return "", err
+		}
+		a.stdinParts = []string{csvPart, tmplPart}
+	}
+	return a.stdinParts[part], nil
+}
+
+// splitStdin splits text at the first line equal to the separator (the line is dropped).
+func splitStdin(text, sep string) (string, string, error) {
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		next := len(text)
+		if end >= 0 {
+			end += start
+			next = end + 1
+		} else {
+			end = len(text)
+		}
+		if strings.TrimSuffix(text[start:end], "\r") == sep {
+			return text[:start], text[next:], nil
+		}
+		start = next
+	}
+	return "", "", fmt.Errorf("no %q separator line in stdin (--stdin-sep)", sep)
+}
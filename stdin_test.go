@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSplitStdin(t *testing.T) {
+	tests := []struct {
+		text, before, after string
+		wantErr             bool
+	}{
+		{"tmpl\n---\na,b\n1,2\n", "tmpl\n", "a,b\n1,2\n", false},
+		{"tmpl\r\n---\r\na,b\r\n", "tmpl\r\n", "a,b\r\n", false},
+		{"---\na,b\n", "", "a,b\n", false},
+		{"tmpl\n---", "tmpl\n", "", false},
+		{"a\n----\nb\n---\nc\n", "a\n----\nb\n", "c\n", false},
+		{"tmpl\na,b\n", "", "", true},
+	}
+	for _, tt := range tests {
+		before, after, err := splitStdin(tt.text, "---")
+		if (err != nil) != tt.wantErr || before != tt.before || after != tt.after {
+			t.Errorf("splitStdin(%q) = %q, %q, %v, want %q, %q, error %v", tt.text, before, after, err, tt.before, tt.after, tt.wantErr)
+		}
+	}
+}
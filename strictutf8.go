@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportedOffsets is the maximal number of invalid byte offsets listed in an error.
+const maxReportedOffsets = 10
+
+// checkUTF8 returns an error listing the byte offsets of the invalid UTF-8 sequences of b.
+func checkUTF8(b []byte) error {
+	if utf8.Valid(b) {
+		return nil
+	}
+	var offsets []string
+	var count int
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size <= 1 {
+			if count < maxReportedOffsets {
+				offsets = append(offsets, fmt.Sprint(i))
+			}
+			count++
+		}
+		i += max(size, 1)
+	}
+	more := ""
+	if count > maxReportedOffsets {
+		more = ", ..."
+	}
+	return fmt.Errorf("%d invalid UTF-8 sequences at byte offsets %s%s", count, strings.Join(offsets, ", "), more)
+}
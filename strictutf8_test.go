@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestCheckUTF8(t *testing.T) {
+	if err := checkUTF8([]byte("héllo, wörld")); err != nil {
+		t.Errorf("valid UTF-8: %v", err)
+	}
+	err := checkUTF8([]byte("caf\xe9 cr\xe8me"))
+	if err == nil || err.Error() != "2 invalid UTF-8 sequences at byte offsets 3, 7" {
+		t.Errorf("checkUTF8 = %v", err)
+	}
+	many := make([]byte, maxReportedOffsets+2)
+	for i := range many {
+		many[i] = 0xff
+	}
+	err = checkUTF8(many)
+	if want := "12 invalid UTF-8 sequences at byte offsets 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, ..."; err == nil || err.Error() != want {
+		t.Errorf("checkUTF8 = %v, want %s", err, want)
+	}
+}
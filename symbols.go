@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// symbolClasses maps the boolean-ish and status values (in lower case) to a symbol class.
+var symbolClasses = map[string]string{
+	"true": "ok", "yes": "ok", "y": "ok", "1": "ok", "ok": "ok", "pass": "ok", "passed": "ok", "done": "ok", "success": "ok",
+	"false": "ko", "no": "ko", "n": "ko", "0": "ko", "ko": "ko", "fail": "ko", "failed": "ko", "error": "ko", "failure": "ko",
+	"warn": "warn", "warning": "warn", "pending": "warn", "partial": "warn", "skipped": "warn", "unknown": "warn",
+}
+
+// symbols are the symbols of the classes, in Unicode and in ASCII (--ascii-symbols).
+var symbols = map[string][2]string{
+	"ok":   {"✓", "OK"},
+	"ko":   {"✗", "FAIL"},
+	"warn": {"⚠", "WARN"},
+}
+
+// symbol returns the status symbol of a value: ✓ for true, yes, ok, pass..., ✗ for false, no,
+// fail, error..., ⚠ for warning, pending, partial... and ? for the other values.
+// With --ascii-symbols (or --ascii-only), the symbols are OK, FAIL and WARN.
+func (a *app) symbol(v any) string {
+	class, ok := symbolClasses[strings.ToLower(strings.TrimSpace(fmt.Sprint(v)))]
+	if !ok {
+		return "?"
+	}
+	if a.asciiSymbols || a.asciiOnly {
+		return symbols[class][1]
+	}
+	return symbols[class][0]
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestSymbol(t *testing.T) {
+	tests := []struct {
+		in         any
+		want, asci string
+	}{
+		{true, "✓", "OK"},
+		{" Yes ", "✓", "OK"},
+		{"FAILED", "✗", "FAIL"},
+		{0, "✗", "FAIL"},
+		{"pending", "⚠", "WARN"},
+		{"maybe", "?", "?"},
+	}
+	a, ascii := &app{}, &app{asciiSymbols: true}
+	for _, tt := range tests {
+		if got := a.symbol(tt.in); got != tt.want {
+			t.Errorf("symbol(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := ascii.symbol(tt.in); got != tt.asci {
+			t.Errorf("ascii symbol(%v) = %q, want %q", tt.in, got, tt.asci)
+		}
+	}
+}
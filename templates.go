@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// fieldRef matches a ${field} reference of the output name.
+var fieldRef = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// fieldName matches a (possibly dotted) field name usable as a template field.
+var fieldName = regexp.MustCompile(`^[\pL_][\pL\pN_]*(\.[\pL_][\pL\pN_]*)*$`)
+
+// expandFields replaces every ${field} reference by the template action printing the field,
+// so that simple output names need neither template syntax nor functions.
+func expandFields(text, leftDelim, rightDelim string) (string, error) {
+	var err error
+	text = fieldRef.ReplaceAllStringFunc(text, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		if !fieldName.MatchString(name) {
+			err = fmt.Errorf("invalid field reference %q", ref)
+			return ref
+		}
+		return leftDelim + "." + name + rightDelim
+	})
+	return text, err
+}
+
+// expandRaw replaces every raw block (text between the open and close markers)
+// by a template action printing the text verbatim, so the text can contain
+// template delimiters.
+func expandRaw(text, open, close, leftDelim, rightDelim string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, open)
+		if start < 0 {
+			b.WriteString(text)
+			return b.String(), nil
+		}
+		end := strings.Index(text[start+len(open):], close)
+		if end < 0 {
+			return "", fmt.Errorf("raw block opened with %s is not closed with %s", open, close)
+		}
+		raw := text[start+len(open) : start+len(open)+end]
+		b.WriteString(text[:start])
+		b.WriteString(leftDelim + strconv.Quote(raw) + rightDelim)
+		text = text[start+len(open)+end+len(close):]
+	}
+}
+
+// expandGlob returns the files matching the pattern, sorted,
+// or the pattern itself if it is not a glob or matches nothing
+// (it is then a file name, or the template content).
+func expandGlob(pattern, leftDelim string) []string {
+	if !strings.ContainsAny(pattern, "*?[") || strings.Contains(pattern, leftDelim) {
+		return []string{pattern}
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil || len(matches) == 0 {
+		return []string{pattern}
+	}
+	return matches
+}
+
+// templateText is the text of a template file and the name it is defined with.
+type templateText struct {
+	name string
+	text string
+}
+
+// readPartials reads the partial templates (all --template but the first),
+// each named after its file base name, followed by the library templates.
+// Two different files with the same name are an error, as one would hide the other.
+func (a *app) readPartials() ([]templateText, error) {
+	partials := make([]templateText, 0, len(a.partials)+len(a.library))
+	paths := make(map[string]string, len(a.partials)+len(a.library))
+	for _, t := range a.library {
+		paths[t.name] = filepath.Join(a.templateDir, filepath.FromSlash(t.name))
+	}
+	for _, p := range a.partials {
+		name := filepath.Base(p)
+		if other, ok := paths[name]; ok {
+			if filepath.Clean(other) == filepath.Clean(p) {
+				continue
+			}
+			return nil, fmt.Errorf("templates %s and %s have the same name %q", other, p, name)
+		}
+		paths[name] = p
+		text, err := a.content(p)
+		if err != nil {
+			return nil, fmt.Errorf("read template: %w", err)
+		}
+		partials = append(partials, templateText{name: name, text: text})
+	}
+	return append(partials, a.library...), nil
+}
+
+// readLibrary reads all the templates of the --template-dir directory,
+// each named after its path relative to the directory.
+func readLibrary(dir string) ([]templateText, error) {
+	var library []templateText
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read template library: %w", err)
+		}
+		library = append(library, templateText{name: filepath.ToSlash(rel), text: string(data)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return library, nil
+}
+
+// missingKey returns the template option for the missing fields:
+// an error with --strict, else "<no value>".
+func (a *app) missingKey() string {
+	if a.strict {
+		return "missingkey=error"
+	}
+	return "missingkey=default"
+}
+
+// parseName parses a text/template used to render names (output paths),
+// together with the library templates so it can call them.
+func (a *app) parseName(name, text string, funcs template.FuncMap) (*template.Template, error) {
+	tmpl, err := template.New(name).Delims(a.leftDelim, a.rightDelim).Option(a.missingKey()).Funcs(funcs).Parse(text)
+	for _, t := range a.library {
+		if err != nil {
+			break
+		}
+		_, err = tmpl.New(t.name).Parse(t.text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// parseContent parses the content template texts (after replacing the raw blocks)
+// into a single template set. The first text is the one executed, unless
+// entry is the name of another template of the set.
+// If the html option is set, html/template is used instead of text/template.
+// With --engine, the texts are mustache or pongo2 templates instead.
+func (a *app) parseContent(texts []templateText, entry string, funcs template.FuncMap) (executor, error) {
+	switch a.engine {
+	case "mustache":
+		return a.parseMustache(texts)
+	case "pongo2":
+		return a.parsePongo(texts, funcs)
+	}
+	var err error
+	// Replace the raw blocks
+	if a.rawOpen != "" {
+		for i := range texts {
+			texts[i].text, err = expandRaw(texts[i].text, a.rawOpen, a.rawClose, a.leftDelim, a.rightDelim)
+			if err != nil {
+				return nil, fmt.Errorf("read template %s: %w", texts[i].name, err)
+			}
+		}
+	}
+	// Parse the templates
+	if a.html {
+		root, err := htmltemplate.New(texts[0].name).Delims(a.leftDelim, a.rightDelim).Option(a.missingKey()).Funcs(funcs).Parse(texts[0].text)
+		for _, t := range texts[1:] {
+			if err != nil {
+				break
+			}
+			_, err = root.New(t.name).Parse(t.text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse template: %w", err)
+		}
+		if entry != "" {
+			if root = root.Lookup(entry); root == nil {
+				return nil, fmt.Errorf("no template named %q", entry)
+			}
+		}
+		return root, nil
+	}
+	root, err := template.New(texts[0].name).Delims(a.leftDelim, a.rightDelim).Option(a.missingKey()).Funcs(funcs).Parse(texts[0].text)
+	for _, t := range texts[1:] {
+		if err != nil {
+			break
+		}
+		_, err = root.New(t.name).Parse(t.text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	if entry != "" {
+		if root = root.Lookup(entry); root == nil {
+			return nil, fmt.Errorf("no template named %q", entry)
+		}
+	}
+	return root, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Example_expandRaw() {
+	text, _ := expandRaw("Hello {{.Name}}: <raw>{{ not an action }}</raw>!", "<raw>", "</raw>", "{{", "}}")
+	fmt.Println(text)
+	// Output: Hello {{.Name}}: {{"{{ not an action }}"}}!
+}
+
+func TestRawDelims(t *testing.T) {
+	got := renderCSV(t, "Name\nAnn\n", `{{range .}}{{.Name}} [[ {{.Name}} ]] "quoted"{{end}}`, "--raw-delims", "[[ ]]")
+	if want := `Ann  {{.Name}}  "quoted"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := expandRaw("a <raw> b", "<raw>", "</raw>", "{{", "}}"); err == nil {
+		t.Error("unclosed raw block: no error")
+	}
+}
+
+func TestExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.tmpl"), "")
+	writeFile(t, filepath.Join(dir, "a.tmpl"), "")
+	glob := filepath.Join(dir, "*.tmpl")
+	want := []string{filepath.Join(dir, "a.tmpl"), filepath.Join(dir, "b.tmpl")}
+	if got := expandGlob(glob, "{{"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expandGlob(%q) = %q, want %q", glob, got, want)
+	}
+	// not a glob, no match, or template content: kept as is
+	for _, p := range []string{"letter.tmpl", filepath.Join(dir, "*.txt"), "{{index . 0}}*"} {
+		if got := expandGlob(p, "{{"); len(got) != 1 || got[0] != p {
+			t.Errorf("expandGlob(%q) = %q", p, got)
+		}
+	}
+}
+
+func TestPartials(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "parts", "greet.tmpl"), "Hello {{.Name}}")
+	writeFile(t, filepath.Join(dir, "parts", "bye.tmpl"), "Bye {{.Name}}")
+	got := renderCSV(t, "Name\nAnn\n", `{{range .}}{{template "greet.tmpl" .}}, {{template "bye.tmpl" .}}{{end}}`,
+		"-t", filepath.Join(dir, "parts", "*.tmpl"))
+	if want := "Hello Ann, Bye Ann"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	tmpl := `{{define "short"}}{{range .}}{{.Name}} {{end}}{{end}}long`
+	if got := renderCSV(t, "Name\nAnn\nBob\n", tmpl, "--entry", "short"); got != "Ann Bob " {
+		t.Errorf("--entry short: got %q", got)
+	}
+	if got := renderCSV(t, "Name\nAnn\n", tmpl); got != "long" {
+		t.Errorf("no --entry: got %q", got)
+	}
+	a := &app{leftDelim: "{{", rightDelim: "}}"}
+	if _, err := a.parseContent([]templateText{{"content", tmpl}}, "missing", nil); err == nil {
+		t.Error("unknown entry: no error")
+	}
+}
+
+func TestTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	writeFile(t, filepath.Join(lib, "greet"), "Hello {{.}}")
+	writeFile(t, filepath.Join(lib, "names", "slug"), "{{.Name}}-{{.Id}}")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Id,Name\n1,Ann\n")
+	err := runCLI("-i", csv, "-t", `{{template "greet" .Name}}`, "--template-dir", lib,
+		"-o", filepath.Join(dir, `{{template "names/slug" .}}.txt`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Ann-1.txt"))
+	if err != nil || string(data) != "Hello Ann" {
+		t.Errorf("Ann-1.txt = %q (%v)", data, err)
+	}
+	if _, err := readLibrary(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing library: no error")
+	}
+}
+
+func TestStrict(t *testing.T) {
+	tmpl := "{{range .}}{{.Name}}:{{.Nmae}}{{end}}"
+	if got := renderCSV(t, "Name\nAnn\n", tmpl); got != "Ann:<no value>" {
+		t.Errorf("without --strict: got %q", got)
+	}
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\n")
+	if err := runCLI("-i", csv, "-t", tmpl, "-o", filepath.Join(dir, "out.txt"), "--strict"); err == nil {
+		t.Error("missing field with --strict: no error")
+	}
+	if err := runCLI("-i", csv, "-t", "x", "-o", filepath.Join(dir, "{{.Nmae}}.txt"), "--strict"); err == nil {
+		t.Error("missing field in the output name with --strict: no error")
+	}
+}
+
+func TestExpandFields(t *testing.T) {
+	tests := []struct {
+		in, left, right, want string
+		wantErr               bool
+	}{
+		{"out/${Name}.txt", "{{", "}}", "out/{{.Name}}.txt", false},
+		{"${a.b}_${Élève}", "<<", ">>", "<<.a.b>>_<<.Élève>>", false},
+		{"no field.txt", "{{", "}}", "no field.txt", false},
+		{"${1x}.txt", "{{", "}}", "", true},
+		{"${a b}.txt", "{{", "}}", "", true},
+	}
+	for _, tt := range tests {
+		got, err := expandFields(tt.in, tt.left, tt.right)
+		if (err != nil) != tt.wantErr || err == nil && got != tt.want {
+			t.Errorf("expandFields(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOutFieldRefs(t *testing.T) {
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nAnn\nBob\n")
+	if err := runCLI("-i", csv, "-t", "{{.Name}}", "-o", filepath.Join(dir, "out", "${Name}-${_index_}.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Ann-1.txt": "Ann", "Bob-2.txt": "Bob"}
+	if got := readTree(t, filepath.Join(dir, "out")); !reflect.DeepEqual(got, want) {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestPartialNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a", "p.tmpl"), "A")
+	writeFile(t, filepath.Join(dir, "b", "p.tmpl"), "B")
+	a := &app{partials: []string{filepath.Join(dir, "a", "p.tmpl"), filepath.Join(dir, "a", "p.tmpl")}}
+	partials, err := a.readPartials()
+	if err != nil || len(partials) != 1 || partials[0].name != "p.tmpl" {
+		t.Errorf("the same file twice: %v, %v", partials, err)
+	}
+	a.partials = []string{filepath.Join(dir, "a", "p.tmpl"), filepath.Join(dir, "b", "p.tmpl")}
+	if _, err := a.readPartials(); err == nil {
+		t.Error("two partials with the same name: no error")
+	}
+	a = &app{partials: []string{filepath.Join(dir, "a", "p.tmpl")}, templateDir: filepath.Join(dir, "b"), library: []templateText{{"p.tmpl", "B"}}}
+	if _, err := a.readPartials(); err == nil {
+		t.Error("a partial with the name of a library template: no error")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// totalField is the field set to true in the synthetic totals rows.
+const totalField = "_is_total_"
+
+// total is a --totals entry: a column and its aggregation (sum, avg, min, max or count).
+type total struct {
+	column string
+	op     string
+}
+
+// parseTotals parses the --totals values of the form column=op.
+func parseTotals(specs []string) ([]total, error) {
+	totals := make([]total, 0, len(specs))
+	for _, spec := range specs {
+		column, op, ok := strings.Cut(spec, "=")
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid total %q (expected column=op)", spec)
+		}
+		switch op {
+		case "sum", "avg", "min", "max", "count":
+		default:
+			return nil, fmt.Errorf("unknown aggregation %q in %q", op, spec)
+		}
+		totals = append(totals, total{column: column, op: op})
+	}
+	return totals, nil
+}
+
+// totalsRow returns the synthetic row holding the totals of the rows.
+// All the other columns are empty and the totalField is true.
+func (a *app) totalsRow(rows []map[string]any) map[string]any {
+	row := make(map[string]any, len(a.headers)+len(a.totals)+1)
+	for _, h := range a.headers {
+		row[h] = ""
+	}
+	for _, t := range a.totals {
+		row[t.column] = a.round.apply(t.column, t.compute(rows))
+	}
+	nestFields(row)
+	row[totalField] = true
+	return row
+}
+
+// compute aggregates the numeric values of the column; other values are ignored
+// (count counts the non empty values).
+// The result is an int if it has no fractional part, else a float64.
+func (t total) compute(rows []map[string]any) any {
+	var result float64
+	var count int
+	for _, row := range rows {
+		v, _ := getField(row, t.column)
+		if t.op == "count" {
+			if v != nil && v != "" {
+				count++
+			}
+			continue
+		}
+		f, ok := toNumber(v, true)
+		if !ok {
+			continue
+		}
+		count++
+		switch {
+		case t.op == "min" && (count == 1 || f < result),
+			t.op == "max" && (count == 1 || f > result):
+			result = f
+		case t.op == "sum", t.op == "avg":
+			result += f
+		}
+	}
+	switch t.op {
+	case "count":
+		return count
+	case "avg":
+		if count == 0 {
+			return ""
+		}
+		result /= float64(count)
+	}
+	if result == math.Trunc(result) && math.Abs(result) < math.MaxInt32 {
+		return int(result)
+	}
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(result, 'g', 15, 64), 64)
+	return v
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTotals(t *testing.T) {
+	got, err := parseTotals([]string{"Amount=sum", "Qty=avg"})
+	want := []total{{"Amount", "sum"}, {"Qty", "avg"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTotals = %v, %v, want %v", got, err, want)
+	}
+	for _, spec := range []string{"Amount", "=sum", "Amount=median"} {
+		if _, err := parseTotals([]string{spec}); err == nil {
+			t.Errorf("parseTotals(%q): no error", spec)
+		}
+	}
+}
+
+func TestTotalCompute(t *testing.T) {
+	rows := []map[string]any{
+		{"Amount": "10"}, {"Amount": "2.5"}, {"Amount": "n/a"}, {"Amount": ""}, {"Amount": "-4"},
+	}
+	tests := map[string]any{
+		"sum":   8.5,
+		"avg":   2.83333333333333,
+		"min":   -4,
+		"max":   10,
+		"count": 4,
+	}
+	for op, want := range tests {
+		if got := (total{"Amount", op}).compute(rows); got != want {
+			t.Errorf("%s = %#v, want %#v", op, got, want)
+		}
+	}
+	if got := (total{"Amount", "avg"}).compute(nil); got != "" {
+		t.Errorf("avg of no rows = %#v, want empty", got)
+	}
+}
+
+func TestTotalsRender(t *testing.T) {
+	csv := "Item,Amount\nbolt,3\nnut,4\n"
+	tmpl := "{{range .}}{{if ._is_total_}}total{{else}}{{.Item}}{{end}}={{.Amount}} {{end}}"
+	if got, want := renderCSV(t, csv, tmpl, "--totals", "Amount=sum"), "bolt=3 nut=4 total=7 "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// copyListFile is the name of the file, at the root of a template tree,
+// listing the patterns of the files to copy verbatim instead of rendering them.
+// Each line is a pattern (see path.Match) matched against the relative path or the
+// base name; empty lines and lines starting with # are ignored.
+const copyListFile = ".csvplatecopy"
+
+// modeListFile is the name of the file, at the root of a template tree,
+// setting the permissions of generated files. Each line is a pattern (as in
+// copyListFile) followed by an octal mode; the last matching line wins.
+const modeListFile = ".csvplatemode"
+
+// treeFile is a file of the template tree.
+type treeFile struct {
+	// rel is the slash separated path relative to the tree root
+	rel string
+	// name renders the output path (relative to the output root)
+	name *template.Template
+	// content renders the file content, if nil the file is copied from raw
+	content executor
+	// raw is the content of files copied verbatim
+	raw []byte
+	// skipIf is the condition, from the skip-if pragma, to not generate the file
+	skipIf *template.Template
+	// mode is the permissions of the generated file, if not 0
+	mode fs.FileMode
+}
+
+// skipPragma extracts the skip-if pragma from the first line of a template file:
+//
+//	{{/* skip-if: eq .Type "basic" */}}
+//
+// It returns the template text without the pragma line and the parsed condition (or nil).
+func (a *app) skipPragma(text string, funcs template.FuncMap) (string, *template.Template, error) {
+	pragma := regexp.MustCompile(`^` + regexp.QuoteMeta(a.leftDelim) + `-?\s*/\*\s*skip-if:(.*?)\*/\s*-?` + regexp.QuoteMeta(a.rightDelim) + `[ \t]*(\r?\n|$)`)
+	m := pragma.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, nil, nil
+	}
+	expr := a.leftDelim + text[m[2]:m[3]] + a.rightDelim
+	cond, err := template.New("skip-if").Delims(a.leftDelim, a.rightDelim).Funcs(funcs).Parse(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse skip-if pragma: %w", err)
+	}
+	return text[m[1]:], cond, nil
+}
+
+// skipped reports whether the skip-if condition of the file is true for data.
+func (file treeFile) skipped(data any) (bool, error) {
+	if file.skipIf == nil {
+		return false, nil
+	}
+	var result strings.Builder
+	if err := file.skipIf.Execute(&result, data); err != nil {
+		return false, err
+	}
+	return parseCondition(result.String())
+}
+
+// copyPatterns returns the patterns of the files to copy verbatim:
+// the --copy-ext extensions and the patterns listed in the copy list file.
+func (a *app) copyPatterns(root string) ([]string, error) {
+	patterns := make([]string, 0, len(a.copyExt))
+	for _, ext := range a.copyExt {
+		patterns = append(patterns, "*."+strings.TrimPrefix(ext, "."))
+	}
+	lines, err := readTreeList(root, copyListFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q", copyListFile, line)
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// modeRule sets the permissions of the generated files matching the pattern.
+type modeRule struct {
+	pattern string
+	mode    fs.FileMode
+}
+
+// modeRules returns the rules listed in the mode list file,
+// one "pattern mode" per line, e.g. "bin/* 0755".
+func modeRules(root string) ([]modeRule, error) {
+	lines, err := readTreeList(root, modeListFile)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]modeRule, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: expected 'pattern mode' in %q", modeListFile, line)
+		}
+		if _, err := path.Match(fields[0], ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q", modeListFile, fields[0])
+		}
+		mode, err := strconv.ParseUint(fields[1], 8, 32)
+		if err != nil || mode > 0o777 {
+			return nil, fmt.Errorf("%s: invalid mode %q", modeListFile, fields[1])
+		}
+		rules = append(rules, modeRule{pattern: fields[0], mode: fs.FileMode(mode)})
+	}
+	return rules, nil
+}
+
+// readTreeList returns the lines of a list file at the root of the template tree,
+// without the empty lines and the comments (starting with #).
+// A missing file is an empty list.
+func readTreeList(root, name string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// matchAny reports whether the relative path, or its base name, matches one of the patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTree parses all files of the template directory,
+// both their (relative) paths and their contents.
+func (a *app) loadTree(root string, funcs template.FuncMap) ([]treeFile, error) {
+	copyPatterns, err := a.copyPatterns(root)
+	if err != nil {
+		return nil, err
+	}
+	modes, err := modeRules(root)
+	if err != nil {
+		return nil, err
+	}
+	partials, err := a.readPartials()
+	if err != nil {
+		return nil, err
+	}
+	var files []treeFile
+	err = filepath.WalkDir(root, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, fullPath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == copyListFile || rel == modeListFile {
+			return nil
+		}
+		name, err := a.parseName(rel, rel, funcs)
+		if err != nil {
+			return fmt.Errorf("parse file name %s: %w", rel, err)
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return fmt.Errorf("read template: %w", err)
+		}
+		file := treeFile{rel: rel, name: name}
+		// Keep the permissions of executable files, unless overridden
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode()&0o111 != 0 {
+			file.mode = info.Mode().Perm()
+		}
+		for _, rule := range modes {
+			if matchAny([]string{rule.pattern}, rel) {
+				file.mode = rule.mode
+			}
+		}
+		if matchAny(copyPatterns, rel) {
+			file.raw = data
+		} else {
+			text, skipIf, err := a.skipPragma(string(data), funcs)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rel, err)
+			}
+			file.skipIf = skipIf
+			file.content, err = a.parseContent(append([]templateText{{rel, text}}, partials...), "", funcs)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rel, err)
+			}
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// write renders the file content for data, or copies it if it is not a template.
+func (file treeFile) write(w io.Writer, data any) error {
+	if file.content == nil {
+		_, err := w.Write(file.raw)
+		return err
+	}
+	return file.content.Execute(w, data)
+}
+
+// writeTree renders the template tree for every unit (row or group).
+// The output root is --out, rendered for the unit if it contains template expressions.
+func (a *app) writeTree(funcs template.FuncMap, units []unit) error {
+	if a.outPath == "-" {
+		return errors.New("a template directory needs an output directory (--out)")
+	}
+	files, err := a.loadTree(a.templatePath, funcs)
+	if err != nil {
+		return err
+	}
+	root := a.outPath
+	if a.outLiteral {
+		root = a.leftDelim + strconv.Quote(root) + a.rightDelim
+	}
+	rootTmpl, err := a.parseName("outfile", root, funcs)
+	if err != nil {
+		return fmt.Errorf("parse output template: %w", err)
+	}
+
+	if !a.progress {
+		a.info("%s\n", a.savedHeader())
+	}
+	var numErrors, renderErrors int
+	var nameBuilder strings.Builder
+	for i, u := range units {
+		a.showProgress(i, len(units))
+		// Render the output root
+		if err := rootTmpl.Execute(&nameBuilder, u.nameDot()); err != nil {
+			nameBuilder.Reset()
+			if err := a.renderFailed(fmt.Errorf("render output name for %s: %w", u.name, err), &renderErrors); err != nil {
+				return err
+			}
+			continue
+		}
+		root := nameBuilder.String()
+		nameBuilder.Reset()
+		if root == "" {
+			return fmt.Errorf("rendered output name for %s is empty", u.name)
+		}
+		for _, file := range files {
+			// Check the skip-if condition
+			skip, err := file.skipped(u.data)
+			if err != nil {
+				if err := a.renderFailed(fmt.Errorf("evaluate skip-if of %s for %s: %w", file.rel, u.name, err), &renderErrors); err != nil {
+					return err
+				}
+				continue
+			}
+			if skip {
+				continue
+			}
+			// Render the file path
+			if err := file.name.Execute(&nameBuilder, u.nameDot()); err != nil {
+				nameBuilder.Reset()
+				if err := a.renderFailed(fmt.Errorf("render file name %s for %s: %w", file.rel, u.name, err), &renderErrors); err != nil {
+					return err
+				}
+				continue
+			}
+			rel := nameBuilder.String()
+			nameBuilder.Reset()
+			if strings.Trim(rel, "/") == "" {
+				return fmt.Errorf("rendered file name %s for %s is empty", file.rel, u.name)
+			}
+			outName := filepath.Join(root, filepath.FromSlash(rel))
+			// Get the file writer, the copied files are neither encoded nor post-processed
+			if file.mode != 0 && a.archive != nil {
+				a.archive.setMode(outName, file.mode)
+			}
+			var f io.WriteCloser
+			if file.content == nil {
+				f, err = a.verbatimWriter(outName)
+			} else {
+				f, err = a.writer(outName)
+			}
+			if err != nil {
+				numErrors++
+				a.recordFailure(outName, u.rows, err)
+				fmt.Fprintf(os.Stderr, "  %s: %v\n", outName, err)
+				continue
+			}
+			// Render (or copy) the file content
+			if err := file.write(f, u.data); err != nil {
+				abort(f)
+				a.recordFailure(outName, u.rows, err)
+				if err := a.renderFailed(fmt.Errorf("render template for %s: %w", outName, err), &renderErrors); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := f.Close(); err != nil {
+				a.recordFailure(outName, u.rows, err)
+				return fmt.Errorf("close %s: %w", outName, err)
+			}
+			a.recordOutput(outName, u.rows, f)
+			if file.mode != 0 && !a.dryRun && a.archive == nil {
+				if err := os.Chmod(outName, file.mode); err != nil {
+					return fmt.Errorf("set permissions of %s: %w", outName, err)
+				}
+			}
+			if err := a.recordAudit(outName, outputSink(outName), u.rows); err != nil {
+				return err
+			}
+			a.saved(outName, f)
+		}
+		if err := a.recordLatest(root, u.nameDot()); err != nil {
+			return err
+		}
+	}
+
+	a.showProgress(len(units), len(units))
+
+	a.changeSummary()
+	return runErrors(numErrors, renderErrors)
+}
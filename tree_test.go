@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// readTree returns the files of the directory, by slash separated relative path.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestTemplateTree(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "scaffold")
+	writeFile(t, filepath.Join(tree, "README.md"), "# {{.Name}}\n")
+	writeFile(t, filepath.Join(tree, "cmd", "{{.Name}}", "main.go"), "package main // {{.Lang}}\n")
+	csv := filepath.Join(dir, "projects.csv")
+	writeFile(t, csv, "Name,Lang\nfoo,go\nbar,rust\n")
+	out := filepath.Join(dir, "out")
+	if err := runCLI("-i", csv, "-t", tree, "-o", filepath.Join(out, "{{.Name}}")); err != nil {
+		t.Fatal(err)
+	}
+	got := readTree(t, out)
+	want := map[string]string{
+		"foo/README.md":       "# foo\n",
+		"foo/cmd/foo/main.go": "package main // go\n",
+		"bar/README.md":       "# bar\n",
+		"bar/cmd/bar/main.go": "package main // rust\n",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got files %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+	if err := runCLI("-i", csv, "-t", tree); err == nil {
+		t.Error("template tree without --out: no error")
+	}
+}
+
+func TestTreeCopyVerbatim(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "site")
+	// not valid templates: they can only be copied
+	image := "\x89PNG\r\n\x1a\n{{\xff\xfe}}"
+	writeFile(t, filepath.Join(tree, "logo.png"), image)
+	writeFile(t, filepath.Join(tree, "vendor", "lib.js"), "x = '{{'")
+	writeFile(t, filepath.Join(tree, copyListFile), "# copied as is\nvendor/*.js\n")
+	writeFile(t, filepath.Join(tree, "index.html"), "<h1>{{.Name}}</h1>")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nfoo\n")
+	out := filepath.Join(dir, "out")
+	if err := runCLI("-i", csv, "-t", tree, "-o", out, "--copy-ext", "png"); err != nil {
+		t.Fatal(err)
+	}
+	got := readTree(t, out)
+	want := map[string]string{"logo.png": image, "vendor/lib.js": "x = '{{'", "index.html": "<h1>foo</h1>"}
+	if len(got) != len(want) {
+		t.Errorf("got files %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	patterns := []string{"*.png", "vendor/*"}
+	for rel, want := range map[string]bool{
+		"logo.png":          true,
+		"img/logo.png":      true,
+		"vendor/lib.js":     true,
+		"vendor/sub/lib.js": false,
+		"index.html":        false,
+	} {
+		if got := matchAny(patterns, rel); got != want {
+			t.Errorf("matchAny(%s) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestTreeSkipIf(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(tree, "ci.yml"), "{{/* skip-if: eq .Plan \"basic\" */}}\nplan: {{.Plan}}\n")
+	writeFile(t, filepath.Join(tree, "README"), "{{.Name}}")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name,Plan\nfoo,basic\nbar,pro\n")
+	out := filepath.Join(dir, "out")
+	if err := runCLI("-i", csv, "-t", tree, "-o", filepath.Join(out, "{{.Name}}")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "foo", "ci.yml")); err == nil {
+		t.Error("foo/ci.yml generated for the basic plan")
+	}
+	// the pragma line is removed
+	if got := readTree(t, out)["bar/ci.yml"]; got != "plan: pro\n" {
+		t.Errorf("bar/ci.yml = %q, want %q", got, "plan: pro\n")
+	}
+}
+
+func TestSkipPragma(t *testing.T) {
+	a := &app{leftDelim: "<<", rightDelim: ">>"}
+	text, cond, err := a.skipPragma("<<- /* skip-if: .Off */ ->>\r\nbody", nil)
+	if err != nil || cond == nil || text != "body" {
+		t.Errorf("skipPragma = %q, %v, %v", text, cond, err)
+	}
+	text, cond, _ = a.skipPragma("body\n<</* skip-if: .Off */>>", nil)
+	if cond != nil || text != "body\n<</* skip-if: .Off */>>" {
+		t.Errorf("pragma not on the first line: %q, %v", text, cond)
+	}
+	if _, _, err := a.skipPragma("<</* skip-if: eq .A */>>\n", nil); err != nil {
+		t.Errorf("condition with missing argument parsed: %v", err)
+	}
+	if _, _, err := a.skipPragma("<</* skip-if: ) */>>\n", nil); err == nil {
+		t.Error("invalid condition: no error")
+	}
+}
+
+func TestTreeModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on windows")
+	}
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(tree, "run.sh"), "#!/bin/sh\necho {{.Name}}\n")
+	if err := os.Chmod(filepath.Join(tree, "run.sh"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(tree, "bin", "tool"), "tool")
+	writeFile(t, filepath.Join(tree, "secret.env"), "KEY=1")
+	writeFile(t, filepath.Join(tree, modeListFile), "bin/* 0755\n*.env 0644\nsecret.env 0600\n")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nfoo\n")
+	out := filepath.Join(dir, "out")
+	if err := runCLI("-i", csv, "-t", tree, "-o", out); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]fs.FileMode{"run.sh": 0o750, "bin/tool": 0o755, "secret.env": 0o600} {
+		info, err := os.Stat(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %o, want %o", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, modeListFile)); err == nil {
+		t.Errorf("%s copied in the output", modeListFile)
+	}
+
+	writeFile(t, filepath.Join(tree, modeListFile), "bin/* rwx\n")
+	if err := runCLI("-i", csv, "-t", tree, "-o", out, "-f"); err == nil {
+		t.Error("invalid mode: no error")
+	}
+}
+
+func TestTreeCopyPNG(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 16)
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "site")
+	writeFile(t, filepath.Join(tree, "logo.png"), b.String())
+	writeFile(t, filepath.Join(tree, "index.html"), "<h1>{{.Name}}</h1>\n")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nfoo\n")
+	out := filepath.Join(dir, "out")
+	if err := runCLI("-i", csv, "-t", tree, "-o", out, "--copy-ext", "png", "--crlf", "--bom", "--banner", "generated"); err != nil {
+		t.Fatal(err)
+	}
+	got := readTree(t, out)
+	if got["logo.png"] != b.String() {
+		t.Errorf("logo.png is modified by the output chain")
+	}
+	decoded, err := png.Decode(strings.NewReader(got["logo.png"]))
+	if err != nil || decoded.Bounds() != img.Bounds() {
+		t.Errorf("decode logo.png: %v", err)
+	}
+	if html := got["index.html"]; !strings.HasPrefix(html, "\ufeff") || !strings.HasSuffix(html, "<h1>foo</h1>\r\n") {
+		t.Errorf("index.html = %q, want the BOM, the banner and CRLF", html)
+	}
+}
+
+func TestTreeDryRunNameErrors(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "site")
+	writeFile(t, filepath.Join(tree, "index.html"), "{{.Name}}")
+	writeFile(t, filepath.Join(tree, "{{.Name.Missing}}.txt"), "{{.Name}}")
+	csv := filepath.Join(dir, "in.csv")
+	writeFile(t, csv, "Name\nfoo\nbar\n")
+	// the name errors are counted, the other files are still checked
+	err := runCLI("-i", csv, "-t", tree, "-o", filepath.Join(dir, "out", "{{.Name}}"), "--dry-run")
+	if err == nil || err.Error() != "2 files could not be rendered" {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); err == nil {
+		t.Error("dry run wrote files")
+	}
+}
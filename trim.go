@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+// trimRecord removes the leading and trailing whitespace of all cells of the record
+// and, if collapse is set, replaces the inner whitespace runs by a single space.
+func trimRecord(record []string, collapse bool) {
+	for i, cell := range record {
+		if collapse {
+			record[i] = strings.Join(strings.Fields(cell), " ")
+		} else {
+			record[i] = strings.TrimSpace(cell)
+		}
+	}
+}
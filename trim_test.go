@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrimRecord(t *testing.T) {
+	record := []string{"  Ann ", "a \t b\n c", ""}
+	trimRecord(record, false)
+	if want := []string{"Ann", "a \t b\n c", ""}; !reflect.DeepEqual(record, want) {
+		t.Errorf("trim = %q, want %q", record, want)
+	}
+	trimRecord(record, true)
+	if want := []string{"Ann", "a b c", ""}; !reflect.DeepEqual(record, want) {
+		t.Errorf("collapse = %q, want %q", record, want)
+	}
+	if got := renderCSV(t, " Name , Age\n Ann ,  30 \n", "{{range .}}[{{.Name}}][{{.Age}}]{{end}}", "--trim"); got != "[Ann][30]" {
+		t.Errorf("--trim: got %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInferType(t *testing.T) {
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"42", 42},
+		{"-3.5", -3.5},
+		{"1e3", 1000.0},
+		{"007", "007"},
+		{"TRUE", true},
+		{"false", false},
+		{"2024-01-31", date},
+		{"2024-01-31T10:20:30Z", date.Add(10*time.Hour + 20*time.Minute + 30*time.Second)},
+		{"31/01/2024", "31/01/2024"},
+		{"", ""},
+		{"Paris", "Paris"},
+	}
+	for _, tt := range tests {
+		got := inferType(tt.in)
+		if tm, ok := got.(time.Time); ok {
+			if !tm.Equal(tt.want.(time.Time)) {
+				t.Errorf("inferType(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("inferType(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInferTypesRender(t *testing.T) {
+	csv := "N,F,B,D,S\n2,1.5,true,2024-02-29,x\n"
+	tmpl := `{{range .}}{{printf "%T %T %T %T %T %T" .N .F .B .D .S ._index_}} {{.D.Weekday}}{{end}}`
+	if got, want := renderCSV(t, csv, tmpl, "--infer-types"), "int float64 bool time.Time string int Thursday"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	tmpl = "{{range .}}{{.N}}{{end}}"
+	if got := renderCSV(t, "N\n10\n9\n", tmpl, "--infer-types", "--sort-by", "N"); got != "910" {
+		t.Errorf("typed sort: got %q, want 910", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// unitDef is a measurement unit: its kind and its value in the base unit of the kind
+// (meter, kilogram, byte). Temperatures are converted apart.
+type unitDef struct {
+	kind   string
+	factor float64
+}
+
+// units are the units known by convertUnit.
+var units = map[string]unitDef{
+	// length
+	"mm":  {"length", 0.001},
+	"cm":  {"length", 0.01},
+	"m":   {"length", 1},
+	"km":  {"length", 1000},
+	"in":  {"length", 0.0254},
+	"ft":  {"length", 0.3048},
+	"yd":  {"length", 0.9144},
+	"mi":  {"length", 1609.344},
+	"nmi": {"length", 1852},
+	// mass
+	"mg": {"mass", 1e-6},
+	"g":  {"mass", 0.001},
+	"kg": {"mass", 1},
+	"t":  {"mass", 1000},
+	"oz": {"mass", 0.028349523125},
+	"lb": {"mass", 0.45359237},
+	// data size
+	"bit": {"data", 0.125},
+	"B":   {"data", 1},
+	"kB":  {"data", 1e3},
+	"MB":  {"data", 1e6},
+	"GB":  {"data", 1e9},
+	"TB":  {"data", 1e12},
+	"PB":  {"data", 1e15},
+	"KiB": {"data", 1 << 10},
+	"MiB": {"data", 1 << 20},
+	"GiB": {"data", 1 << 30},
+	"TiB": {"data", 1 << 40},
+	"PiB": {"data", 1 << 50},
+	// temperature (see toKelvin and fromKelvin)
+	"C": {"temperature", 0},
+	"F": {"temperature", 0},
+	"K": {"temperature", 0},
+}
+
+// convertUnit converts the value v from a unit to another unit of the same kind:
+// convertUnit "in" "cm" 2 gives 5.08.
+func convertUnit(from, to string, v any) (float64, error) {
+	f, ok := toNumber(v, true)
+	if !ok {
+		return 0, fmt.Errorf("convertUnit: %v is not a number", v)
+	}
+	u1, ok := units[from]
+	if !ok {
+		return 0, fmt.Errorf("convertUnit: unknown unit %q", from)
+	}
+	u2, ok := units[to]
+	if !ok {
+		return 0, fmt.Errorf("convertUnit: unknown unit %q", to)
+	}
+	if u1.kind != u2.kind {
+		return 0, fmt.Errorf("convertUnit: cannot convert %s (%s) to %s (%s)", from, u1.kind, to, u2.kind)
+	}
+	var result float64
+	if u1.kind == "temperature" {
+		result = fromKelvin(to, toKelvin(from, f))
+	} else {
+		result = f * u1.factor / u2.factor
+	}
+	// cut the float artifacts (0.30000000000000004)
+	result, _ = strconv.ParseFloat(strconv.FormatFloat(result, 'g', 12, 64), 64)
+	return result, nil
+}
+
+// toKelvin converts a temperature in C, F or K to kelvins.
+func toKelvin(unit string, t float64) float64 {
+	switch unit {
+	case "C":
+		return t + 273.15
+	case "F":
+		return (t-32)*5/9 + 273.15
+	default:
+		return t
+	}
+}
+
+// fromKelvin converts a temperature in kelvins to C, F or K.
+func fromKelvin(unit string, k float64) float64 {
+	switch unit {
+	case "C":
+		return k - 273.15
+	case "F":
+		return (k-273.15)*9/5 + 32
+	default:
+		return k
+	}
+}
+
+// humanSize formats a number of bytes with the largest fitting prefix,
+// binary ("iec": 1.5 KiB) or decimal ("si": 1.5 kB).
+func humanSize(system string, v any) (string, error) {
+	f, ok := toNumber(v, true)
+	if !ok {
+		return "", fmt.Errorf("humanSize: %v is not a number", v)
+	}
+	var base float64
+	var prefixes []string
+	switch system {
+	case "iec":
+		base, prefixes = 1024, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	case "si":
+		base, prefixes = 1000, []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	default:
+		return "", fmt.Errorf("humanSize: unknown system %q (expected iec or si)", system)
+	}
+	i := 0
+	for math.Abs(f) >= base && i < len(prefixes)-1 {
+		f /= base
+		i++
+	}
+	if i == 0 {
+		return strconv.FormatFloat(f, 'f', -1, 64) + " B", nil
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " " + prefixes[i], nil
+}
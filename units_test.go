@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_convertUnit() {
+	for _, c := range []struct {
+		from, to string
+		v        any
+	}{
+		{"in", "cm", 2},
+		{"mi", "km", "26.2"},
+		{"lb", "kg", 1},
+		{"C", "F", -40},
+		{"F", "C", 212},
+		{"GiB", "MB", 1},
+	} {
+		r, _ := convertUnit(c.from, c.to, c.v)
+		fmt.Println(c.v, c.from, "=", r, c.to)
+	}
+	// Output:
+	// 2 in = 5.08 cm
+	// 26.2 mi = 42.1648128 km
+	// 1 lb = 0.45359237 kg
+	// -40 C = -40 F
+	// 212 F = 100 C
+	// 1 GiB = 1073.741824 MB
+}
+
+func TestConvertUnitErrors(t *testing.T) {
+	for _, c := range [][3]any{{"m", "kg", 1}, {"m", "parsec", 1}, {"m", "km", "far"}} {
+		if _, err := convertUnit(c[0].(string), c[1].(string), c[2]); err == nil {
+			t.Errorf("convertUnit%v: no error", c)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		system string
+		v      any
+		want   string
+	}{
+		{"iec", 512, "512 B"},
+		{"iec", 1536, "1.5 KiB"},
+		{"si", "1500000", "1.5 MB"},
+		{"si", -2000, "-2.0 kB"},
+		{"iec", 1 << 60, "1024.0 PiB"},
+	}
+	for _, tt := range tests {
+		if got, err := humanSize(tt.system, tt.v); err != nil || got != tt.want {
+			t.Errorf("humanSize(%q, %v) = %q, %v, want %q", tt.system, tt.v, got, err, tt.want)
+		}
+	}
+	if _, err := humanSize("jedec", 1); err == nil {
+		t.Error("unknown system: no error")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// The verbosity levels set by --quiet and --verbose.
+const (
+	quiet   = 0
+	normal  = 1
+	verbose = 2
+)
+
+// info prints an informational message on stderr, unless --quiet is set.
+// With --progress, the messages about single files are replaced by the progress counter.
+func (a *app) info(format string, args ...any) {
+	if a.verbosity >= normal {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// warn prints a warning on stderr, unless --quiet is set.
+func (a *app) warn(format string, args ...any) {
+	if a.verbosity >= normal {
+		fmt.Fprintf(os.Stderr, "csvplate: "+format, args...)
+	}
+}
+
+// debug prints a detailed message on stderr, only with --verbose.
+func (a *app) debug(format string, args ...any) {
+	if a.verbosity >= verbose {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// showProgress updates the live progress counter on stderr, if --progress is set.
+// The line is terminated when done reaches total.
+func (a *app) showProgress(done, total int) {
+	if !a.progress || total == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r%d/%d outputs (%d%%)", done, total, done*100/total)
+	if done == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
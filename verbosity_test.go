@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStderr returns what fn prints on stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	fn()
+	os.Stderr = stderr
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestVerbosity(t *testing.T) {
+	for _, level := range []int{quiet, normal, verbose} {
+		a := &app{verbosity: level}
+		got := captureStderr(t, func() {
+			a.info("info\n")
+			a.debug("debug\n")
+		})
+		want := map[int]string{quiet: "", normal: "info\n", verbose: "info\ndebug\n"}[level]
+		if got != want {
+			t.Errorf("verbosity %d: got %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestShowProgress(t *testing.T) {
+	a := &app{progress: true}
+	got := captureStderr(t, func() {
+		for i := range 3 {
+			a.showProgress(i, 2)
+		}
+		a.showProgress(0, 0)
+	})
+	if want := "\r0/2 outputs (0%)\r1/2 outputs (50%)\r2/2 outputs (100%)\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
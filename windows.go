@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// window returns the sliding windows of size consecutive items of the list
+// (rows or any slice): window 2 [a b c] gives [[a b] [b c]].
+// A list shorter than size gives no window.
+func window(size int, list any) ([][]any, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("window: size must be positive, got %d", size)
+	}
+	v, err := sliceValue("window", list)
+	if err != nil {
+		return nil, err
+	}
+	var windows [][]any
+	for i := 0; i+size <= v.Len(); i++ {
+		w := make([]any, size)
+		for j := range w {
+			w[j] = v.Index(i + j).Interface()
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// zipLists returns the lists of the items at the same position in all lists:
+// zip [a b c] [1 2 3] gives [[a 1] [b 2] [c 3]].
+// The result is as long as the shortest list.
+func zipLists(lists ...any) ([][]any, error) {
+	if len(lists) == 0 {
+		return nil, nil
+	}
+	values := make([]reflect.Value, len(lists))
+	length := -1
+	for i, list := range lists {
+		v, err := sliceValue("zip", list)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		if length < 0 || v.Len() < length {
+			length = v.Len()
+		}
+	}
+	tuples := make([][]any, length)
+	for i := range tuples {
+		tuples[i] = make([]any, len(values))
+		for j, v := range values {
+			tuples[i][j] = v.Index(i).Interface()
+		}
+	}
+	return tuples, nil
+}
+
+// sliceValue returns the reflect value of list, or an error naming the function if it is not a slice.
+func sliceValue(function string, list any) (reflect.Value, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("%s: expected a slice, got %T", function, list)
+	}
+	return v, nil
+}
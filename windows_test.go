@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Example_window() {
+	w, _ := window(2, []string{"a", "b", "c"})
+	fmt.Println(w)
+	w, _ = window(4, []string{"a", "b", "c"})
+	fmt.Println(len(w))
+	// Output:
+	// [[a b] [b c]]
+	// 0
+}
+
+func Example_zipLists() {
+	z, _ := zipLists([]string{"a", "b", "c"}, []int{1, 2})
+	fmt.Println(z)
+	// Output:
+	// [[a 1] [b 2]]
+}
+
+func TestWindowErrors(t *testing.T) {
+	if _, err := window(0, []int{1}); err == nil {
+		t.Error("window 0: no error")
+	}
+	if _, err := zipLists([]int{1}, 2); err == nil || err.Error() != "zip: expected a slice, got int" {
+		t.Errorf("zip of an int: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapper returns a process function re-wrapping the paragraphs of the output
+// (lines separated by empty lines) to the given width.
+// The lines between two marker lines are kept verbatim, the marker lines are removed.
+func wrapper(width int, marker string) func(io.Reader, io.Writer) error {
+	return func(in io.Reader, out io.Writer) error {
+		w := bufio.NewWriter(out)
+		var paragraph []string
+		flush := func() {
+			if len(paragraph) > 0 {
+				writeWrapped(w, paragraph, width)
+				paragraph = paragraph[:0]
+			}
+		}
+		verbatim := false
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 1<<30)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case marker != "" && strings.TrimSpace(line) == marker:
+				flush()
+				verbatim = !verbatim
+			case verbatim:
+				w.WriteString(line + "\n")
+			case strings.TrimSpace(line) == "":
+				flush()
+				w.WriteString(line + "\n")
+			default:
+				paragraph = append(paragraph, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		flush()
+		return w.Flush()
+	}
+}
+
+// writeWrapped writes the words of the paragraph lines in lines of at most width
+// characters (longer words stay alone on their line).
+// The indentation of the first line is used for all lines.
+func writeWrapped(w *bufio.Writer, paragraph []string, width int) {
+	first := paragraph[0]
+	indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	var line strings.Builder
+	var length int
+	for _, p := range paragraph {
+		for _, word := range strings.Fields(p) {
+			n := utf8.RuneCountInString(word)
+			if length > 0 && length+1+n > width {
+				w.WriteString(line.String() + "\n")
+				line.Reset()
+				length = 0
+			}
+			if length == 0 {
+				line.WriteString(indent)
+				length = utf8.RuneCountInString(indent)
+			} else {
+				line.WriteByte(' ')
+				length++
+			}
+			line.WriteString(word)
+			length += n
+		}
+	}
+	if length > 0 {
+		w.WriteString(line.String() + "\n")
+	}
+}
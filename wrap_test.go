@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+func Example_wrapper() {
+	text := `  The quick brown fox jumps
+over the lazy dog.
+
+%nowrap
+keep   this line
+%nowrap
+a verylongwordthatdoesnotfit b
+`
+	wrapper(16, "%nowrap")(strings.NewReader(text), os.Stdout)
+	// Output:
+	//   The quick
+	//   brown fox
+	//   jumps over the
+	//   lazy dog.
+	//
+	// keep   this line
+	// a
+	// verylongwordthatdoesnotfit
+	// b
+}